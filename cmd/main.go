@@ -1,31 +1,166 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"os/exec"
 	"os/signal"
-	"pkg"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/slipperypenguin/flipboard-scraper/pkg"
+)
+
+// Exit codes let scripts branch on why a run failed. Other failures, such
+// as an unreadable baseline or jobs file, exit with 1.
+const (
+	exitOK                = 0
+	exitNoURLs            = 2
+	exitAllFailed         = 3
+	exitExportFailed      = 4
+	exitPartialFailure    = 5
+	exitNoArticles        = 6
+	exitHookFailed        = 7
+	exitInvalidURLs       = 8
+	exitPartialNoArticles = 9
+	exitSchemaViolation   = 10
 )
 
 func main() {
 	var (
 		urls           = flag.String("urls", "", "Comma-separated list of Flipboard magazine URLs to scrape")
-		format         = flag.String("format", "csv", "Export format (csv or sqlite)")
+		urlsFile       = flag.String("urls-file", "", "File of Flipboard magazine URLs to scrape, one per line; blank lines and # comments are ignored")
+		dedup          = flag.Bool("dedup", false, "Drop articles that appear in more than one magazine, matching URLs without query parameters")
+		cacheDir       = flag.String("cache-dir", "", "Directory caching responses so re-runs reuse them; entries never expire, delete it to refetch")
+		respectRobots  = flag.Bool("respect-robots", false, "Skip magazines whose path robots.txt disallows")
+		userAgentsFile = flag.String("user-agents-file", "", "File of User-Agent strings, one per line, rotated across requests")
+		format         = flag.String("format", "csv", "Export format (csv, json, ndjson, xlsx, sqlite, html, markdown, ics, rss, sitemap, clickhouse, elastic or queue)")
 		output         = flag.String("output", "articles", "Output file (without extension)")
+		outputDir      = flag.String("output-dir", "", "Write exports under this directory in a subdirectory per day, e.g. out/2024/06/01/articles.csv")
 		concurrent     = flag.Int("concurrent", 3, "Maximum number of concurrent requests")
 		rateLimit      = flag.Float64("rate-limit", 1.0, "Maximum requests per second")
+		burst          = flag.Int("burst", 1, "Requests allowed to start at once before -rate-limit paces them")
 		timeoutSeconds = flag.Int("timeout", 120, "Timeout in seconds")
+		requestTimeout = flag.Int("request-timeout", 30, "Timeout for each HTTP request in seconds")
+		minArticles    = flag.Int("min-articles", 0, "Warn about magazines that yield fewer articles than this (0 disables)")
+		strictMin      = flag.Bool("strict-min-articles", false, "Treat magazines under -min-articles as failed instead of warning")
+		errorOnEmpty   = flag.Bool("error-on-empty", false, "Treat magazines that load but yield no articles as failed")
+		continueOnErr  = flag.Bool("continue-on-error", false, "With -jobs, keep scraping a job's other URLs when one fails instead of cancelling them")
+		retries        = flag.Int("retries", 2, "Times to retry a magazine that answers 429, 502, 503 or 504")
+		retryBackoff   = flag.Duration("retry-backoff", time.Second, "Delay before the first retry, doubling for each further retry")
+		slowStart      = flag.Bool("slow-start", false, "Ramp concurrency up from 1 as requests succeed")
+		strictSchema   = flag.Bool("strict-schema", false, "Fail the run if exported articles violate the schema")
+		dsn            = flag.String("dsn", "", "ClickHouse DSN for -format clickhouse, e.g. clickhouse://localhost:9000/analytics")
+		elasticURLs    = flag.String("elastic-urls", "", "Comma-separated Elasticsearch nodes for -format elastic, e.g. http://localhost:9200")
+		feedTitle      = flag.String("feed-title", "Flipboard Articles", "Channel title for -format rss")
+		elasticIndex   = flag.String("elastic-index", "flipboard-articles", "Index -format elastic writes articles to")
+		queueURL       = flag.String("queue-url", "", "Broker URL for -format queue, e.g. nats://localhost:4222")
+		queueTopic     = flag.String("queue-topic", "flipboard.articles", "Topic -format queue publishes new articles to")
+		queueEncoding  = flag.String("queue-encoding", "json", "Message body for -format queue (json or url)")
+		sqliteIDKey    = flag.Bool("sqlite-id-key", false, "Use the stable article ID as the SQLite primary key")
+		sqliteReplace  = flag.Bool("sqlite-replace", false, "Replace SQLite rows for articles already exported instead of skipping them")
+		sqliteConns    = flag.Int("sqlite-max-conns", 1, "Maximum open connections the SQLite export uses")
+		comments       = flag.Bool("comments", false, "Extract top comment previews for each article")
+		related        = flag.Bool("related", false, "Extract related-article links for each article")
+		flippedBy      = flag.Bool("flipped-by", false, "Record who flipped each article into the magazine")
+		openGraph      = flag.Bool("open-graph", false, "Fetch the page of articles missing a title, summary or image and fill them from its Open Graph tags")
+		hostRates      = flag.String("host-rates", "", "Per-host-class rates overriding -rate-limit, e.g. flipboard.com=2,*.nytimes.com=0.5")
+		smoothPacing   = flag.Bool("smooth-pacing", false, "Space requests evenly at -rate-limit instead of allowing short bursts")
+		adaptive       = flag.Bool("adaptive-pacing", false, "Slow down requests to hosts that respond slowly")
+		maxRedirects   = flag.Int("max-redirects", 10, "Maximum redirects to follow per request")
+		proxies        = flag.String("proxy", "", "Proxy for requests (http, https or socks5 URL); a comma-separated list rotates across them")
+		minTLS         = flag.String("min-tls", "1.2", "Oldest TLS version to connect over (1.0, 1.1, 1.2 or 1.3)")
+		preview        = flag.Int("preview", 0, "Stop after collecting this many articles across all URLs (0 scrapes everything)")
+		maxPages       = flag.Int("max-pages", 1, "Follow each magazine's next-page links until this many pages are scraped")
+		maxRecent      = flag.Int("max-recent", 0, "Keep only the N most recent articles of each magazine, undated ones last (0 keeps all)")
+		filterKeyword  = flag.String("filter-keyword", "", "Only export articles whose title or summary contains this text, ignoring case")
+		since          = flag.String("since", "", "Only export articles dated at or after this day (YYYY-MM-DD, UTC) or RFC 3339 time; undated articles are dropped")
+		until          = flag.String("until", "", "Only export articles dated at or before the end of this day (YYYY-MM-DD, UTC) or RFC 3339 time; undated articles are dropped")
+		baseline       = flag.String("baseline", "", "Previous export (.csv or .db); only articles missing from it are output")
+		jobsFile       = flag.String("jobs", "", "JSON file of job specs to run instead of -urls")
+		jobsParallel   = flag.Int("jobs-parallel", 1, "Maximum number of jobs to run at once")
+		rateLimitFile  = flag.String("rate-limit-file", "", "Token file shared by several processes to enforce -rate-limit across all of them")
+		interval       = flag.Duration("interval", 0, "Keep running, starting a new scrape this often (e.g. 1h)")
+		minInterval    = flag.Duration("min-interval", 0, "Shortest time allowed between the starts of scheduled runs")
+		failOnError    = flag.Bool("fail-on-error", false, "Exit with an error if any URL fails, even when others succeed")
+		postHook       = flag.String("post-hook", "", "Shell command to run after a successful export; gets the output path and article count as $1 and $2")
+		failOnHook     = flag.Bool("fail-on-hook", false, "Fail the run if the -post-hook command exits non-zero")
+		strictUTF8     = flag.Bool("strict-utf8", false, "Fail CSV export on invalid UTF-8 instead of replacing it")
+		statsFile      = flag.String("stats-file", "", "Metrics file (.csv or .db) to append each run's stats to")
+		acceptConsent  = flag.Bool("accept-consent", false, "Accept cookie-consent walls automatically and retry the magazine")
+		order          = flag.String("order", "", "Sort articles by \"id\" or \"url\" so repeated runs export identical files; default keeps scrape order")
+		pprofAddr      = flag.String("pprof", "", "Serve net/http/pprof profiles under /debug/pprof on this address (e.g. localhost:6060) while running")
+		debug          = flag.Bool("debug", false, "Log colly's request and response events to stderr, with credentials masked")
+		verbose        = flag.Bool("verbose", false, "Log each magazine request, retry, completion and failure to stderr")
+		updatesFile    = flag.String("updates-file", "", "JSON file remembering each magazine's last-updated time; magazines not updated since are skipped")
+		rejectedFile   = flag.String("rejected-samples", "", "With -debug, write the HTML of items dropped during extraction to this file")
+		rejectedMax    = flag.Int("rejected-samples-max", 20, "Maximum number of dropped items written to -rejected-samples")
+		printConfig    = flag.Bool("print-config", false, "Print the effective configuration as JSON and exit without scraping")
+		cookiesFile    = flag.String("cookies-file", "", "Netscape cookies.txt file whose cookies, e.g. a login session, are sent with requests")
+		allowedHosts   = flag.String("allowed-hosts", "", "Comma-separated hosts, with their subdomains, magazines may be scraped from; default flipboard.com")
+		validate       = flag.Bool("validate", false, "Check the magazine URLs without fetching them, print a report and exit")
+		notifyWebhook  = flag.String("notify-webhook", "", "Slack or Discord webhook URL to post a run summary to")
+		notifyFormat   = flag.String("notify-format", "slack", "Webhook platform for -notify-webhook (slack or discord)")
+		summaryFormat  = flag.String("summary-format", "text", "Run summary printed at the end of each run (text or json)")
+		summaryFile    = flag.String("summary-file", "", "File to write the run summary to instead of stdout, replaced on each run")
 	)
+	headers := make(map[string]string)
+	flag.Func("header", "Header sent with every Flipboard request, as \"Name: value\"; repeat for several", func(value string) error {
+		name, value, err := parseHeader(value)
+		if err != nil {
+			return err
+		}
+		headers[name] = value
+		return nil
+	})
 
 	flag.Parse()
 
-	if *urls == "" {
-		log.Fatal("Please provide Flipboard magazine URLs using the -urls flag")
+	if *urls == "" && *urlsFile == "" && *jobsFile == "" {
+		fail(exitNoURLs, "Please provide Flipboard magazine URLs using the -urls or -urls-file flag")
+	}
+	if _, err := pkg.ParseTLSVersion(*minTLS); err != nil {
+		log.Fatalf("Invalid -min-tls: %v", err)
+	}
+	switch pkg.ArticleOrder(*order) {
+	case pkg.OrderNone, pkg.OrderByID, pkg.OrderByURL:
+	default:
+		log.Fatalf("Unknown -order %q (want id or url)", *order)
+	}
+	// Single runs report each URL's outcome, so they always continue
+	if *continueOnErr && *jobsFile == "" {
+		log.Fatal("-continue-on-error only applies to -jobs runs")
+	}
+	if *summaryFormat != "text" && *summaryFormat != "json" {
+		log.Fatalf("Unknown -summary-format %q (want text or json)", *summaryFormat)
+	}
+	filter := pkg.FilterOptions{Keyword: *filterKeyword}
+	var err error
+	if filter.Since, err = parseFilterDate(*since, false); err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+	if filter.Until, err = parseFilterDate(*until, true); err != nil {
+		log.Fatalf("Invalid -until: %v", err)
+	}
+	filtering := filter != pkg.FilterOptions{}
+
+	// A JSON summary on stdout must be the only thing there, so progress
+	// messages go to stderr instead
+	progress := io.Writer(os.Stdout)
+	if *summaryFormat == "json" && *summaryFile == "" {
+		progress = os.Stderr
 	}
 
 	// Create context that can be cancelled
@@ -37,53 +172,711 @@ func main() {
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		<-c
-		fmt.Println("\nReceived interrupt signal. Cleaning up...")
+		fmt.Fprintln(progress, "\nReceived interrupt signal. Cleaning up...")
 		cancel()
 	}()
 
 	// Configure and create scraper
 	config := pkg.ScraperConfig{
-		ConcurrentRequests: *concurrent,
-		RequestsPerSecond:  *rateLimit,
-		Timeout:           time.Duration(*timeoutSeconds) * time.Second,
+		ConcurrentRequests:   *concurrent,
+		RequestsPerSecond:    *rateLimit,
+		Burst:                *burst,
+		Timeout:              time.Duration(*timeoutSeconds) * time.Second,
+		RequestTimeout:       time.Duration(*requestTimeout) * time.Second,
+		MaxRetries:           *retries,
+		RetryBackoff:         *retryBackoff,
+		ContinueOnError:      *continueOnErr,
+		Dedup:                *dedup,
+		MinArticlesPerURL:    *minArticles,
+		StrictMinArticles:    *strictMin,
+		ErrorOnEmpty:         *errorOnEmpty,
+		SlowStart:            *slowStart,
+		SlowStartRamp:        30 * time.Second,
+		ExtractComments:      *comments,
+		ExtractRelated:       *related,
+		ExtractFlippedBy:     *flippedBy,
+		FetchOpenGraph:       *openGraph,
+		SmoothPacing:         *smoothPacing,
+		AdaptivePacing:       *adaptive,
+		MaxRedirects:         *maxRedirects,
+		MinTLSVersion:        *minTLS,
+		MaxArticlesTotal:     *preview,
+		MaxPages:             *maxPages,
+		MaxRecentPerMagazine: *maxRecent,
+		AcceptConsent:        *acceptConsent,
+		RespectRobotsTxt:     *respectRobots,
+		CacheDir:             *cacheDir,
+		Debug:                *debug,
+		Order:                pkg.ArticleOrder(*order),
+	}
+	if *userAgentsFile != "" {
+		userAgents, err := readListFile(*userAgentsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.UserAgents = userAgents
+	}
+	if len(headers) > 0 {
+		config.Headers = headers
+	}
+	if *cookiesFile != "" {
+		cookies, err := pkg.LoadCookiesFile(*cookiesFile)
+		if err != nil {
+			log.Fatalf("Failed to load -cookies-file: %v", err)
+		}
+		config.Cookies = cookies
+	}
+	if *proxies != "" {
+		for _, proxy := range strings.Split(*proxies, ",") {
+			config.ProxyURLs = append(config.ProxyURLs, strings.TrimSpace(proxy))
+		}
+	}
+	if *hostRates != "" {
+		rates, err := parseHostRates(*hostRates)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.HostRates = rates
+	}
+	if *rateLimitFile != "" {
+		limiter, err := pkg.NewFileRateLimiter(*rateLimitFile, *rateLimit)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.RateLimiter = limiter
+	}
+
+	exportOpts := pkg.ExportOptions{
+		StrictUTF8:    *strictUTF8,
+		SQLiteIDKey:   *sqliteIDKey,
+		SQLiteReplace: *sqliteReplace,
+		SQLiteMaxOpen: *sqliteConns,
+		DSN:           *dsn,
+		QueueURL:      *queueURL,
+		QueueTopic:    *queueTopic,
+		QueueEncoding: pkg.QueueEncoding(*queueEncoding),
+		ElasticIndex:  *elasticIndex,
+		FeedTitle:     *feedTitle,
+	}
+	if *elasticURLs != "" {
+		for _, address := range strings.Split(*elasticURLs, ",") {
+			exportOpts.ElasticAddresses = append(exportOpts.ElasticAddresses, strings.TrimSpace(address))
+		}
+	}
+
+	if *allowedHosts != "" {
+		for _, host := range strings.Split(*allowedHosts, ",") {
+			config.AllowedHosts = append(config.AllowedHosts, strings.TrimSpace(host))
+		}
 	}
-	scraper := pkg.NewMagazineScraper(config)
 
 	// Split URLs and clean them
-	urlList := strings.Split(*urls, ",")
-	for i, url := range urlList {
-		urlList[i] = strings.TrimSpace(url)
+	var urlList []string
+	if *urls != "" {
+		urlList = strings.Split(*urls, ",")
+		for i, url := range urlList {
+			urlList[i] = strings.TrimSpace(url)
+		}
+	}
+	if *urlsFile != "" {
+		fileURLs, err := readListFile(*urlsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		urlList = append(urlList, fileURLs...)
+	}
+
+	var jobs []pkg.Job
+	if *jobsFile != "" {
+		var err error
+		if jobs, err = pkg.LoadJobs(*jobsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *validate {
+		all := urlList
+		for _, job := range jobs {
+			all = append(all, job.URLs...)
+		}
+		if invalid := printValidation(os.Stdout, all, config.AllowedHosts); invalid > 0 {
+			os.Exit(exitInvalidURLs)
+		}
+		return
+	}
+
+	if len(urlList) > 0 {
+		// Repeats of a magazine, including www. and trailing-slash
+		// variants, would only waste requests
+		unique := pkg.UniqueMagazineURLs(urlList)
+		if removed := len(urlList) - len(unique); removed > 0 {
+			log.Printf("Ignoring %d duplicate magazine URLs", removed)
+		}
+		urlList = unique
+	}
+
+	if *verbose {
+		config.Logger = pkg.NewStdLogger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+
+	if *debug && *rejectedFile != "" {
+		config.RejectedSamples = *rejectedMax
+	}
+
+	// Updates are only tracked for -urls runs; jobs don't share a tracker
+	var updates *pkg.UpdateTracker
+	if *updatesFile != "" && jobs == nil {
+		var err error
+		if updates, err = pkg.LoadUpdateTracker(*updatesFile); err != nil {
+			log.Fatal(err)
+		}
+		config.Updates = updates
+	}
+
+	// Print only once the configuration is complete, before anything is
+	// created on disk
+	if *printConfig {
+		configs := effectiveConfigs(config, urlList, *format, *output, jobs)
+		if err := writeConfigs(os.Stdout, configs); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *debug && *rejectedFile != "" {
+		file, err := os.Create(*rejectedFile)
+		if err != nil {
+			log.Fatalf("Failed to create rejected samples file: %v", err)
+		}
+		defer file.Close()
+		config.RejectedSamplesOutput = file
 	}
 
-	// Scrape URLs
-	articles, err := scraper.ScrapeURLs(ctx, urlList)
+	if server := pprofServer(*pprofAddr); server != nil {
+		go func() {
+			log.Printf("Serving profiles on http://%s/debug/pprof/", server.Addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Profiling server stopped: %v", err)
+			}
+		}()
+		defer server.Close()
+	}
+
+	// Batch mode runs every job in the spec and exits
+	if jobs != nil {
+		export := func(format, output string, articles []pkg.Article) error {
+			if filtering {
+				articles = pkg.FilterArticles(articles, filter)
+			}
+			output, err := datedOutput(*outputDir, format, output, time.Now())
+			if err != nil {
+				return err
+			}
+			return exportArticles(progress, format, output, articles, exportOpts)
+		}
+
+		failed := 0
+		for _, result := range pkg.RunJobs(ctx, jobs, config, *jobsParallel, export) {
+			if result.Err != nil {
+				failed++
+				log.Printf("Job %s failed: %v", result.Job.Name, result.Err)
+				continue
+			}
+			fmt.Fprintf(progress, "Job %s: %d articles\n", result.Job.Name, result.Articles)
+		}
+		if failed == len(jobs) {
+			fail(exitAllFailed, "All %d jobs failed", failed)
+		}
+		if failed > 0 && *failOnError {
+			fail(exitPartialFailure, "%d of %d jobs failed", failed, len(jobs))
+		}
+		return
+	}
+
+	// Scheduled runs share one scraper so its connections stay warm
+	scraper, err := pkg.NewMagazineScraper(config)
 	if err != nil {
-		log.Printf("Warning: Some URLs may have failed: %v", err)
+		log.Fatalf("Invalid configuration: %v", err)
 	}
+	defer scraper.Close()
+
+	// runOnce scrapes and exports once, returning an *exitError on failure
+	runOnce := func(ctx context.Context) error {
+		// Drop update times staged by an earlier run whose export failed
+		if updates != nil {
+			updates.Discard()
+		}
 
-	if len(articles) == 0 {
-		log.Fatal("No articles were scraped")
+		// Scrape URLs
+		results, err := scraper.ScrapeURLsDetailed(ctx, urlList)
+		defer func() {
+			if err := writeSummary(*summaryFormat, *summaryFile, results, scraper.LastStats()); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}()
+		articles, failed := pkg.MergeResults(results, config.Order)
+		if config.Dedup {
+			articles = pkg.Deduplicate(articles)
+		}
+		allFailed := err != nil
+		if err == nil {
+			// Some URLs failed while others succeeded
+			err = failed
+		}
+		if *statsFile != "" {
+			if err := pkg.AppendStats(*statsFile, scraper.LastStats()); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+		if len(articles) == 0 && err == nil && scraper.LastStats().Unchanged > 0 {
+			fmt.Fprintln(progress, "No magazine has been updated since the last run")
+			return nil
+		}
+		switch code := scrapeExitCode(len(articles), err, allFailed, *failOnError); {
+		case errors.Is(err, pkg.ErrBlockedByInterstitial):
+			return &exitError{code, errors.New("Flipboard served a consent or login wall instead of the magazine")}
+		case errors.Is(err, pkg.ErrSelectorsMatchedNothing), errors.Is(err, pkg.ErrNoArticlesFound):
+			return &exitError{code, errors.New("Pages loaded but no articles matched; Flipboard markup may have changed")}
+		case code != exitOK:
+			return &exitError{code, fmt.Errorf("Scraping failed: %w", err)}
+		case err != nil:
+			log.Printf("Warning: Some URLs may have failed: %v", err)
+		}
+
+		fmt.Fprintf(progress, "Found %d articles\n", len(articles))
+
+		// Keep only articles that weren't in the previous export
+		if *baseline != "" {
+			previous, err := pkg.LoadBaseline(*baseline)
+			if err != nil {
+				return &exitError{1, fmt.Errorf("Failed to load baseline: %w", err)}
+			}
+			articles = pkg.NewArticlesSince(articles, previous)
+			fmt.Fprintf(progress, "%d articles are new since %s\n", len(articles), *baseline)
+		}
+
+		if filtering {
+			articles = pkg.FilterArticles(articles, filter)
+			fmt.Fprintf(progress, "%d articles match the filter\n", len(articles))
+		}
+
+		// Validate the articles against the schema before anything is
+		// written or their magazines are marked as seen
+		if err := pkg.ValidateArticles(articles); err != nil {
+			if *strictSchema {
+				return &exitError{exitSchemaViolation, fmt.Errorf("Articles failed schema validation: %w", err)}
+			}
+			log.Printf("Warning: %v", err)
+		}
+
+		// Export based on chosen format, into today's directory with -output-dir
+		output, err := datedOutput(*outputDir, *format, *output, time.Now())
+		if err != nil {
+			return &exitError{exitExportFailed, err}
+		}
+		if err := exportArticles(progress, *format, output, articles, exportOpts); err != nil {
+			return &exitError{exitExportFailed, err}
+		}
+
+		// Only remember update times once their articles are exported
+		if updates != nil {
+			if err := updates.Save(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+
+		// Post a summary for the team; a failed notification doesn't fail the run
+		if *notifyWebhook != "" {
+			notifier := pkg.NewNotifyExporter(*notifyWebhook, pkg.WithNotifyFormat(pkg.NotifyFormat(*notifyFormat)))
+			if err := notifier.Export(articles); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+
+		// Hand the export off to the user's hook
+		if *postHook != "" {
+			if err := runPostHook(ctx, *postHook, pkg.ExportPath(*format, output), len(articles)); err != nil {
+				if *failOnHook {
+					return &exitError{exitHookFailed, err}
+				}
+				log.Printf("Warning: %v", err)
+			}
+		}
+		return nil
 	}
 
-	fmt.Printf("Found %d articles\n", len(articles))
+	// Interval mode keeps scraping on a schedule until interrupted
+	if *interval > 0 || *minInterval > 0 {
+		scheduler := &pkg.Scheduler{
+			Interval:    *interval,
+			MinInterval: *minInterval,
+			Run:         runOnce,
+			OnError: func(err error) {
+				log.Printf("Run failed: %v", err)
+			},
+		}
+		scheduler.Start(ctx)
+		return
+	}
 
-	// Export based on chosen format
-	switch *format {
-	case "csv":
-		exporter := pkg.NewCSVExporter(*output + ".csv")
-		if err := exporter.Export(articles); err != nil {
-			log.Fatalf("Failed to export to CSV: %v", err)
+	if err := runOnce(ctx); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			fail(exitErr.code, "%v", exitErr.err)
 		}
-		fmt.Printf("Articles exported to %s.csv\n", *output)
+		fail(1, "%v", err)
+	}
+}
+
+// effectiveConfig is one run's fully-merged configuration as printed by
+// -print-config
+type effectiveConfig struct {
+	Job    string            `json:"job,omitempty"`
+	URLs   []string          `json:"urls"`
+	Format string            `json:"format"`
+	Output string            `json:"output"`
+	Config pkg.ScraperConfig `json:"config"`
+
+	// Settings ScraperConfig leaves out of its JSON. Only header names are
+	// printed since their values are often credentials.
+	Headers []string `json:"headers,omitempty"`
+	Cookies int      `json:"cookies,omitempty"`
+	Verbose bool     `json:"verbose,omitempty"`
+	Updates bool     `json:"updates,omitempty"`
+}
+
+// newEffectiveConfig describes a run with config
+func newEffectiveConfig(job string, urls []string, format, output string, config pkg.ScraperConfig) effectiveConfig {
+	headers := make([]string, 0, len(config.Headers))
+	for name := range config.Headers {
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+	return effectiveConfig{
+		Job:     job,
+		URLs:    urls,
+		Format:  format,
+		Output:  output,
+		Config:  config,
+		Headers: headers,
+		Cookies: len(config.Cookies),
+		Verbose: config.Logger != nil,
+		Updates: config.Updates != nil,
+	}
+}
+
+// effectiveConfigs merges config, built from defaults and flags, with each
+// job's overrides. Without jobs it describes the single -urls run.
+func effectiveConfigs(config pkg.ScraperConfig, urls []string, format, output string, jobs []pkg.Job) []effectiveConfig {
+	if jobs == nil {
+		return []effectiveConfig{newEffectiveConfig("", urls, format, output, config)}
+	}
 
-	case "sqlite":
-		exporter := pkg.NewSQLiteExporter(*output + ".db")
-		if err := exporter.Export(articles); err != nil {
-			log.Fatalf("Failed to export to SQLite: %v", err)
+	configs := make([]effectiveConfig, len(jobs))
+	for i, job := range jobs {
+		configs[i] = newEffectiveConfig(job.Name, job.URLs, job.Format, job.Output, job.Config.Apply(config))
+	}
+	return configs
+}
+
+// writeConfigs prints configs as indented JSON
+func writeConfigs(w io.Writer, configs []effectiveConfig) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(configs); err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+	return nil
+}
+
+// parseHostRates parses a comma-separated list of pattern=rate pairs
+func parseHostRates(value string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		pattern, rawRate, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid -host-rates entry %q, want pattern=rate", pair)
 		}
-		fmt.Printf("Articles exported to %s.db\n", *output)
+		rate, err := strconv.ParseFloat(rawRate, 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate in -host-rates entry %q", pair)
+		}
+		rates[pattern] = rate
+	}
+	return rates, nil
+}
+
+// exitError carries the exit code for a failed run
+type exitError struct {
+	code int
+	err  error
+}
 
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// scrapeExitCode classifies the outcome of a scrape, where allFailed
+// reports that no URL succeeded. Runs that produced articles succeed unless
+// failOnError is set and some URL failed.
+func scrapeExitCode(articles int, err error, allFailed, failOnError bool) int {
+	switch {
+	case articles == 0 && (errors.Is(err, pkg.ErrSelectorsMatchedNothing) || errors.Is(err, pkg.ErrNoArticlesFound) || errors.Is(err, pkg.ErrBlockedByInterstitial)):
+		return exitNoArticles
+	case articles == 0 && allFailed:
+		return exitAllFailed
+	case articles == 0 && err != nil:
+		return exitPartialNoArticles
+	case articles == 0:
+		return exitNoArticles
+	case err != nil && failOnError:
+		return exitPartialFailure
 	default:
-		log.Fatalf("Unsupported export format: %s", *format)
+		return exitOK
+	}
+}
+
+// parseHeader splits a -header value of the form "Name: value"
+func parseHeader(header string) (string, string, error) {
+	name, value, ok := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid header %q (want \"Name: value\")", header)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// parseFilterDate parses a -since or -until value: an RFC 3339 time, or a
+// YYYY-MM-DD day in UTC. A day ending a range stands for its last instant,
+// so the whole day is included. An empty value leaves that end open.
+func parseFilterDate(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want YYYY-MM-DD or RFC 3339: %q", value)
+	}
+	if end {
+		day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return day, nil
+}
+
+// printValidation prints whether each of urls is valid, and why not, and
+// returns how many are invalid
+func printValidation(w io.Writer, urls, allowedHosts []string) int {
+	invalid := 0
+	for i, err := range pkg.ValidateURLs(urls, allowedHosts...) {
+		if err != nil {
+			invalid++
+			fmt.Fprintf(w, "  invalid: %v\n", err)
+		} else {
+			fmt.Fprintf(w, "  ok: %s\n", urls[i])
+		}
+	}
+	fmt.Fprintf(w, "%d of %d URLs are valid\n", len(urls)-invalid, len(urls))
+	return invalid
+}
+
+// printResults prints a per-URL summary: each magazine's article count, or
+// why it was skipped or failed
+func printResults(w io.Writer, results []pkg.ScrapeResult) {
+	if len(results) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Per-URL summary:")
+	for _, result := range results {
+		switch {
+		case errors.Is(result.Err, pkg.ErrMagazineUnchanged):
+			fmt.Fprintf(w, "  %s: unchanged\n", result.URL)
+		case errors.Is(result.Err, pkg.ErrArticleLimitReached):
+			fmt.Fprintf(w, "  %s: %d articles (stopped at the article limit)\n", result.URL, len(result.Articles))
+		case result.Err != nil:
+			fmt.Fprintf(w, "  %s: failed: %v\n", result.URL, result.Err)
+		case result.Warning != nil:
+			fmt.Fprintf(w, "  %s: %d articles (warning: %v)\n", result.URL, len(result.Articles), result.Warning)
+		default:
+			fmt.Fprintf(w, "  %s: %d articles\n", result.URL, len(result.Articles))
+		}
+	}
+}
+
+// runSummary is the machine-readable summary of a run written by
+// -summary-format json
+type runSummary struct {
+	Started         time.Time    `json:"started"`
+	DurationSeconds float64      `json:"duration_seconds"`
+	URLs            int          `json:"urls"`
+	Articles        int          `json:"articles"`
+	Failures        int          `json:"failures"`
+	Unchanged       int          `json:"unchanged"`
+	TooFewArticles  int          `json:"too_few_articles"`
+	Results         []urlSummary `json:"results"`
+}
+
+// urlSummary is one magazine's outcome within a runSummary. Status is ok,
+// warning, failed, unchanged or limit.
+type urlSummary struct {
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	Articles int    `json:"articles"`
+	Error    string `json:"error,omitempty"`
+	Warning  string `json:"warning,omitempty"`
+}
+
+// newRunSummary combines a run's stats with its per-URL results
+func newRunSummary(results []pkg.ScrapeResult, stats pkg.ScrapeStats) runSummary {
+	summary := runSummary{
+		Started:         stats.Started,
+		DurationSeconds: stats.Duration.Seconds(),
+		URLs:            stats.URLs,
+		Articles:        stats.Articles,
+		Failures:        stats.Failures,
+		Unchanged:       stats.Unchanged,
+		TooFewArticles:  stats.TooFewArticles,
+		Results:         make([]urlSummary, 0, len(results)),
+	}
+	for _, result := range results {
+		url := urlSummary{URL: result.URL, Status: "ok", Articles: len(result.Articles)}
+		switch {
+		case errors.Is(result.Err, pkg.ErrMagazineUnchanged):
+			url.Status = "unchanged"
+		case errors.Is(result.Err, pkg.ErrArticleLimitReached):
+			url.Status = "limit"
+		case result.Err != nil:
+			url.Status, url.Error = "failed", result.Err.Error()
+		case result.Warning != nil:
+			url.Status, url.Warning = "warning", result.Warning.Error()
+		}
+		summary.Results = append(summary.Results, url)
+	}
+	return summary
+}
+
+// writeSummary writes the run summary in format (text or json) to path, or
+// to stdout when path is empty
+func writeSummary(format, path string, results []pkg.ScrapeResult, stats pkg.ScrapeStats) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create summary file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if format != "json" {
+		printResults(w, results)
+		return nil
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newRunSummary(results, stats)); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// pprofServer returns the server for -pprof, serving the net/http/pprof
+// handlers under /debug/pprof/ on addr, or nil when addr is empty and
+// profiling is off
+func pprofServer(addr string) *http.Server {
+	if addr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return &http.Server{Addr: addr, Handler: mux}
+}
+
+// readListFile reads one entry per line from path, such as magazine URLs or
+// User-Agent strings, skipping blank lines and lines starting with #
+func readListFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, nil
+}
+
+// fail logs the message and exits with code
+func fail(code int, format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(code)
+}
+
+// datedOutput places output under dir in a year/month/day subdirectory for
+// day, e.g. out/2024/06/01/articles, creating the directories as needed. An
+// empty dir leaves output unchanged. The queue format's output names the
+// file of articles already published, which must outlive the day, so it
+// goes directly under dir.
+func datedOutput(dir, format, output string, day time.Time) (string, error) {
+	if dir == "" {
+		return output, nil
+	}
+	dayDir := filepath.Join(dir, day.Format("2006"), day.Format("01"), day.Format("02"))
+	if format == "queue" {
+		dayDir = dir
+	}
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return filepath.Join(dayDir, output), nil
+}
+
+// exportArticles writes articles to output (without extension) in format,
+// reporting where they went to progress
+func exportArticles(progress io.Writer, format, output string, articles []pkg.Article, opts pkg.ExportOptions) error {
+	exporter, err := opts.NewExporter(format, output)
+	if err != nil {
+		return err
+	}
+	if err := exporter.Export(articles); err != nil {
+		return fmt.Errorf("failed to export %s: %w", format, err)
+	}
+
+	if path := pkg.ExportPath(format, output); path != "" && format != "queue" {
+		fmt.Fprintf(progress, "Articles exported to %s\n", path)
+	} else {
+		fmt.Fprintf(progress, "Articles exported to %s\n", format)
+	}
+	return nil
+}
+
+// runPostHook runs command through the shell with the export's path and
+// article count as positional arguments and as FLIPBOARD_OUTPUT and
+// FLIPBOARD_ARTICLE_COUNT. The hook's output is logged.
+func runPostHook(ctx context.Context, command, outputPath string, articles int) error {
+	count := strconv.Itoa(articles)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command, "post-hook", outputPath, count)
+	cmd.Env = append(os.Environ(),
+		"FLIPBOARD_OUTPUT="+outputPath,
+		"FLIPBOARD_ARTICLE_COUNT="+count,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if trimmed := bytes.TrimSpace(out); len(trimmed) > 0 {
+		log.Printf("post-hook: %s", trimmed)
+	}
+	if err != nil {
+		return fmt.Errorf("post-hook %q failed: %w", command, err)
 	}
+	return nil
 }
@@ -0,0 +1,572 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slipperypenguin/flipboard-scraper/pkg"
+)
+
+func TestScrapeExitCode(t *testing.T) {
+	partial := fmt.Errorf("scraping error: %w", errors.New("failed to scrape one URL"))
+	tests := []struct {
+		name        string
+		articles    int
+		err         error
+		allFailed   bool
+		failOnError bool
+		want        int
+	}{
+		{"success", 5, nil, false, false, exitOK},
+		{"success with fail-on-error", 5, nil, false, true, exitOK},
+		{"all failed", 0, partial, true, false, exitAllFailed},
+		{"partial without fail-on-error", 5, partial, false, false, exitOK},
+		{"partial with fail-on-error", 5, partial, false, true, exitPartialFailure},
+		{"partial without articles", 0, partial, false, false, exitPartialNoArticles},
+		{"selectors matched nothing", 0, pkg.ErrSelectorsMatchedNothing, false, false, exitNoArticles},
+		{"no articles found", 0, fmt.Errorf("scraping error: %w", pkg.ErrNoArticlesFound), true, false, exitNoArticles},
+		{"blocked by interstitial", 0, fmt.Errorf("scraping error: %w", pkg.ErrBlockedByInterstitial), true, false, exitNoArticles},
+	}
+
+	for _, tt := range tests {
+		if got := scrapeExitCode(tt.articles, tt.err, tt.allFailed, tt.failOnError); got != tt.want {
+			t.Errorf("%s: scrapeExitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExportFailureIsReported(t *testing.T) {
+	if err := exportArticles(io.Discard, "bogus", t.TempDir()+"/out", nil, pkg.ExportOptions{}); err == nil {
+		t.Error("Expected export error for unsupported format, which exits with exitExportFailed")
+	}
+}
+
+func TestExitCodesAreDistinct(t *testing.T) {
+	codes := []int{exitOK, exitNoURLs, exitAllFailed, exitExportFailed, exitPartialFailure, exitNoArticles, exitHookFailed, exitInvalidURLs, exitPartialNoArticles, exitSchemaViolation}
+	seen := make(map[int]bool)
+	for _, code := range codes {
+		if seen[code] {
+			t.Errorf("exit code %d is used for more than one condition", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestRunPostHook(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "articles.csv")
+	envFile := filepath.Join(dir, "env")
+	argsFile := filepath.Join(dir, "args")
+
+	command := fmt.Sprintf(`echo "$FLIPBOARD_OUTPUT $FLIPBOARD_ARTICLE_COUNT" > %q; echo "$1 $2" > %q; echo uploaded`, envFile, argsFile)
+	if err := runPostHook(context.Background(), command, outputPath, 7); err != nil {
+		t.Fatalf("runPostHook failed: %v", err)
+	}
+
+	want := outputPath + " 7"
+	for _, file := range []string{envFile, argsFile} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("hook did not write %s: %v", file, err)
+		}
+		if got := strings.TrimSpace(string(data)); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, want)
+		}
+	}
+}
+
+func TestRunPostHookFailure(t *testing.T) {
+	if err := runPostHook(context.Background(), "exit 3", "articles.csv", 0); err == nil {
+		t.Error("Expected error when the hook exits non-zero")
+	}
+}
+
+func TestPrintConfigAppliesOverridesInOrder(t *testing.T) {
+	// Flags override the defaults, and job overrides take precedence over flags
+	config := pkg.DefaultConfig()
+	config.ConcurrentRequests = 5
+	config.RequestsPerSecond = 2
+	config.Transform = func(a pkg.Article) pkg.Article { return a }
+
+	concurrent := 1
+	jobs := []pkg.Job{
+		{Name: "slow", URLs: []string{"https://flipboard.com/@a/m"}, Format: "csv", Output: "slow", Config: pkg.JobOverrides{ConcurrentRequests: &concurrent}},
+		{Name: "plain", URLs: []string{"https://flipboard.com/@b/m"}, Format: "sqlite", Output: "plain"},
+	}
+
+	var buf strings.Builder
+	if err := writeConfigs(&buf, effectiveConfigs(config, nil, "csv", "articles", jobs)); err != nil {
+		t.Fatalf("writeConfigs failed: %v", err)
+	}
+
+	var printed []effectiveConfig
+	if err := json.Unmarshal([]byte(buf.String()), &printed); err != nil {
+		t.Fatalf("printed config is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(printed) != 2 {
+		t.Fatalf("printed %d configs, want 2", len(printed))
+	}
+	if got := printed[0].Config.ConcurrentRequests; got != 1 {
+		t.Errorf("job override: ConcurrentRequests = %d, want 1", got)
+	}
+	if got := printed[1].Config.ConcurrentRequests; got != 5 {
+		t.Errorf("flag value: ConcurrentRequests = %d, want 5", got)
+	}
+	if got := printed[0].Config.RequestsPerSecond; got != 2 {
+		t.Errorf("flag value: RequestsPerSecond = %v, want 2", got)
+	}
+	if got := printed[1].Config.MaxRedirects; got != 10 {
+		t.Errorf("default: MaxRedirects = %d, want 10", got)
+	}
+	if printed[1].Format != "sqlite" || printed[1].Job != "plain" {
+		t.Errorf("job fields not printed: %+v", printed[1])
+	}
+}
+
+func TestPrintConfigWithoutJobs(t *testing.T) {
+	urls := []string{"https://flipboard.com/@a/m"}
+	configs := effectiveConfigs(pkg.DefaultConfig(), urls, "html", "out", nil)
+	if len(configs) != 1 || configs[0].Format != "html" || configs[0].URLs[0] != urls[0] {
+		t.Errorf("effectiveConfigs() = %+v, want the single -urls run", configs)
+	}
+}
+
+func TestPrintConfigShowsFinalConfig(t *testing.T) {
+	dir := t.TempDir()
+	rejected := filepath.Join(dir, "rejected.html")
+	cookies := filepath.Join(dir, "cookies.txt")
+	if err := os.WriteFile(cookies, []byte("flipboard.com\tTRUE\t/\tTRUE\t0\tsession\tsecret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runCLI(t, "",
+		"-print-config",
+		"-verbose",
+		"-debug",
+		"-rejected-samples", rejected,
+		"-rejected-samples-max", "5",
+		"-updates-file", filepath.Join(dir, "updates.json"),
+		"-cookies-file", cookies,
+		"-header", "Authorization: Bearer secret",
+	)
+	if err != nil {
+		t.Fatalf("-print-config failed: %v\n%s", err, stderr)
+	}
+
+	var printed []effectiveConfig
+	if err := json.Unmarshal(stdout.Bytes(), &printed); err != nil {
+		t.Fatalf("printed config is not valid JSON: %v\n%s", err, stdout)
+	}
+	if len(printed) != 1 {
+		t.Fatalf("printed %d configs, want 1", len(printed))
+	}
+	got := printed[0]
+	if !got.Verbose || !got.Updates || got.Cookies != 1 || got.Config.RejectedSamples != 5 {
+		t.Errorf("printed config misses settings applied after the flags: %+v", got)
+	}
+	if len(got.Headers) != 1 || got.Headers[0] != "Authorization" {
+		t.Errorf("Headers = %v, want [Authorization]", got.Headers)
+	}
+	if strings.Contains(stdout.String(), "secret") {
+		t.Errorf("printed config leaks a credential:\n%s", stdout)
+	}
+	if _, err := os.Stat(rejected); !os.IsNotExist(err) {
+		t.Errorf("-print-config created the rejected samples file (stat error %v)", err)
+	}
+}
+
+func TestParseHostRates(t *testing.T) {
+	rates, err := parseHostRates("flipboard.com=2, *.nytimes.com=0.5")
+	if err != nil {
+		t.Fatalf("parseHostRates() error = %v", err)
+	}
+	if rates["flipboard.com"] != 2 || rates["*.nytimes.com"] != 0.5 || len(rates) != 2 {
+		t.Errorf("parseHostRates() = %v", rates)
+	}
+
+	for _, bad := range []string{"flipboard.com", "=2", "flipboard.com=fast", "flipboard.com=0"} {
+		if _, err := parseHostRates(bad); err == nil {
+			t.Errorf("parseHostRates(%q) should fail", bad)
+		}
+	}
+}
+
+func TestReadListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	contents := "# tech magazines\nhttps://flipboard.com/@user/a\n\n  https://flipboard.com/@user/b  \n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	urls, err := readListFile(path)
+	if err != nil {
+		t.Fatalf("readListFile() error = %v", err)
+	}
+	if len(urls) != 2 || urls[0] != "https://flipboard.com/@user/a" || urls[1] != "https://flipboard.com/@user/b" {
+		t.Errorf("readListFile() = %q", urls)
+	}
+}
+
+func TestPprofServer(t *testing.T) {
+	if pprofServer("") != nil {
+		t.Error("pprofServer(\"\") returned a server with profiling off")
+	}
+}
+
+func TestPprofFlag(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	for _, enabled := range []bool{true, false} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := listener.Addr().String()
+		listener.Close()
+
+		// Interval mode keeps running, as a long scrape would
+		output := filepath.Join(t.TempDir(), "articles")
+		args := []string{"-output", output, "-interval", "1h"}
+		if enabled {
+			args = append(args, "-pprof", addr)
+		}
+		cmd := cliCommand(t, page, args...)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Profiling is set up before the first run, so once that has
+		// exported, the server is listening if it is going to be
+		deadline := time.Now().Add(10 * time.Second)
+		get := func() (*http.Response, error) { return http.Get("http://" + addr + "/debug/pprof/") }
+		for time.Now().Before(deadline) {
+			if _, err := os.Stat(output + ".csv"); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		resp, err := get()
+		for enabled && err != nil && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			resp, err = get()
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+
+		switch {
+		case enabled && err != nil:
+			t.Errorf("-pprof set: GET /debug/pprof/ error = %v", err)
+		case enabled && resp.StatusCode != http.StatusOK:
+			t.Errorf("-pprof set: GET /debug/pprof/ = %d, want %d", resp.StatusCode, http.StatusOK)
+		case !enabled && err == nil:
+			t.Errorf("-pprof unset: GET /debug/pprof/ = %d, want nothing listening", resp.StatusCode)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	results := []pkg.ScrapeResult{
+		{URL: "https://flipboard.com/@user/good", Articles: make([]pkg.Article, 2)},
+		{URL: "https://flipboard.com/@user/broken", Err: errors.New("boom")},
+		{URL: "https://flipboard.com/@user/same", Err: pkg.ErrMagazineUnchanged},
+		{URL: "https://flipboard.com/@user/thin", Articles: make([]pkg.Article, 1), Warning: pkg.ErrTooFewArticles},
+	}
+	var out strings.Builder
+	printResults(&out, results)
+
+	want := `Per-URL summary:
+  https://flipboard.com/@user/good: 2 articles
+  https://flipboard.com/@user/broken: failed: boom
+  https://flipboard.com/@user/same: unchanged
+  https://flipboard.com/@user/thin: 1 articles (warning: fewer articles than expected)
+`
+	if out.String() != want {
+		t.Errorf("printResults() wrote\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestPrintValidation(t *testing.T) {
+	var out strings.Builder
+	invalid := printValidation(&out, []string{
+		"https://flipboard.com/@user/good",
+		"https://example.com/@user/other",
+	}, nil)
+	if invalid != 1 {
+		t.Errorf("printValidation() = %d invalid, want 1", invalid)
+	}
+
+	want := `  ok: https://flipboard.com/@user/good
+  invalid: not a Flipboard URL: https://example.com/@user/other (allowed hosts: flipboard.com)
+1 of 2 URLs are valid
+`
+	if out.String() != want {
+		t.Errorf("printValidation() wrote\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestWriteSummaryJSON(t *testing.T) {
+	results := []pkg.ScrapeResult{
+		{URL: "https://flipboard.com/@user/good", Articles: make([]pkg.Article, 2)},
+		{URL: "https://flipboard.com/@user/broken", Err: errors.New("boom")},
+		{URL: "https://flipboard.com/@user/same", Err: pkg.ErrMagazineUnchanged},
+		{URL: "https://flipboard.com/@user/thin", Articles: make([]pkg.Article, 1), Warning: pkg.ErrTooFewArticles},
+	}
+	stats := pkg.ScrapeStats{
+		Started:        time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		URLs:           4,
+		Articles:       3,
+		Failures:       1,
+		Duration:       1500 * time.Millisecond,
+		Unchanged:      1,
+		TooFewArticles: 1,
+	}
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := writeSummary("json", path, results, stats); err != nil {
+		t.Fatalf("writeSummary() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "started": "2024-06-01T12:00:00Z",
+  "duration_seconds": 1.5,
+  "urls": 4,
+  "articles": 3,
+  "failures": 1,
+  "unchanged": 1,
+  "too_few_articles": 1,
+  "results": [
+    {
+      "url": "https://flipboard.com/@user/good",
+      "status": "ok",
+      "articles": 2
+    },
+    {
+      "url": "https://flipboard.com/@user/broken",
+      "status": "failed",
+      "articles": 0,
+      "error": "boom"
+    },
+    {
+      "url": "https://flipboard.com/@user/same",
+      "status": "unchanged",
+      "articles": 0
+    },
+    {
+      "url": "https://flipboard.com/@user/thin",
+      "status": "warning",
+      "articles": 1,
+      "warning": "fewer articles than expected"
+    }
+  ]
+}
+`
+	if string(data) != want {
+		t.Errorf("JSON summary =\n%s\nwant\n%s", data, want)
+	}
+
+	// The text summary is the per-URL listing
+	if err := writeSummary("text", path, results, stats); err != nil {
+		t.Fatalf("writeSummary(text) error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "Per-URL summary:\n") {
+		t.Errorf("text summary = %q", data)
+	}
+}
+
+func TestDatedOutput(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	day := time.Date(2024, 6, 1, 23, 30, 0, 0, time.UTC)
+
+	output, err := datedOutput(dir, "csv", "articles", day)
+	if err != nil {
+		t.Fatalf("datedOutput() error = %v", err)
+	}
+	want := filepath.Join(dir, "2024", "06", "01", "articles")
+	if output != want {
+		t.Errorf("datedOutput() = %q, want %q", output, want)
+	}
+	if info, err := os.Stat(filepath.Dir(want)); err != nil || !info.IsDir() {
+		t.Fatalf("dated directory not created: %v", err)
+	}
+
+	// Exports land in the dated directory
+	if err := exportArticles(io.Discard, "csv", output, []pkg.Article{{Title: "A", URL: "https://example.com/a"}}, pkg.ExportOptions{}); err != nil {
+		t.Fatalf("exportArticles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2024", "06", "01", "articles.csv")); err != nil {
+		t.Errorf("export not written to the dated directory: %v", err)
+	}
+
+	// Without a directory the output is used as given
+	if output, err := datedOutput("", "csv", "articles", day); err != nil || output != "articles" {
+		t.Errorf("datedOutput(\"\") = %q, %v, want articles unchanged", output, err)
+	}
+
+	// The queue's seen file is shared by every day's runs
+	for _, day := range []time.Time{day, day.AddDate(0, 0, 1)} {
+		output, err := datedOutput(dir, "queue", "articles", day)
+		if err != nil || pkg.ExportPath("queue", output) != filepath.Join(dir, "articles.seen.csv") {
+			t.Errorf("datedOutput(queue) = %q, %v, want the seen file directly under %s", output, err, dir)
+		}
+	}
+}
+
+func TestParseFilterDate(t *testing.T) {
+	since, err := parseFilterDate("2024-03-01", false)
+	if err != nil || !since.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseFilterDate(since) = %v, %v, want the start of the day", since, err)
+	}
+	until, err := parseFilterDate("2024-03-31", true)
+	if err != nil || !until.Equal(time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC)) {
+		t.Errorf("parseFilterDate(until) = %v, %v, want the end of the day", until, err)
+	}
+	exact, err := parseFilterDate("2024-03-31T08:00:00+02:00", true)
+	if err != nil || !exact.Equal(time.Date(2024, 3, 31, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseFilterDate(RFC 3339) = %v, %v, want that exact time", exact, err)
+	}
+	if open, err := parseFilterDate("", true); err != nil || !open.IsZero() {
+		t.Errorf("parseFilterDate(\"\") = %v, %v, want an open end", open, err)
+	}
+	if _, err := parseFilterDate("03/31/2024", false); err == nil {
+		t.Error("parseFilterDate() accepted a non-ISO date")
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	name, value, err := parseHeader("Cookie: a=1; b=2")
+	if err != nil || name != "Cookie" || value != "a=1; b=2" {
+		t.Errorf("parseHeader() = %q, %q, %v", name, value, err)
+	}
+	if _, _, err := parseHeader("no colon"); err == nil {
+		t.Error("parseHeader() accepted a header without a colon")
+	}
+}
+
+// runMainEnv makes the test binary run main instead of the tests, so tests
+// can drive the CLI as a subprocess
+const runMainEnv = "FLIPBOARD_SCRAPER_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs the command against a TLS server serving page for every
+// magazine, with args appended after -urls and -allowed-hosts
+func runCLI(t *testing.T, page string, args ...string) (stdout, stderr *bytes.Buffer, err error) {
+	t.Helper()
+	cmd := cliCommand(t, page, args...)
+	stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return stdout, stderr, cmd.Run()
+}
+
+// cliCommand prepares the command for runCLI, with the TLS server serving
+// page until the test ends
+func cliCommand(t *testing.T, page string, args ...string) *exec.Cmd {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+
+	// Trust the test server through the system roots of the subprocess
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(certFile, cert, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], append([]string{
+		"-urls", server.URL + "/@user/magazine",
+		"-allowed-hosts", "127.0.0.1",
+	}, args...)...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1", "SSL_CERT_FILE="+certFile)
+	return cmd
+}
+
+func TestContinueOnErrorRequiresJobs(t *testing.T) {
+	_, stderr, err := runCLI(t, "", "-continue-on-error", "-output", filepath.Join(t.TempDir(), "articles"))
+	if err == nil {
+		t.Fatal("-continue-on-error without -jobs succeeded, want it rejected")
+	}
+	if !strings.Contains(stderr.String(), "-continue-on-error only applies to -jobs runs") {
+		t.Errorf("stderr = %q, want the -jobs scope explained", stderr)
+	}
+}
+
+func TestJSONSummaryKeepsStdoutParseable(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	stdout, stderr, err := runCLI(t, page,
+		"-output", filepath.Join(t.TempDir(), "articles"),
+		"-summary-format", "json",
+	)
+	if err != nil {
+		t.Fatalf("CLI failed: %v\n%s", err, stderr.String())
+	}
+
+	decoder := json.NewDecoder(stdout)
+	var summary runSummary
+	if err := decoder.Decode(&summary); err != nil {
+		t.Fatalf("stdout is not a JSON summary: %v\n%s", err, stdout.String())
+	}
+	if err := decoder.Decode(new(json.RawMessage)); !errors.Is(err, io.EOF) {
+		t.Errorf("stdout has more after the summary: %v", err)
+	}
+	if len(summary.Results) != 1 {
+		t.Errorf("summary has %d results, want 1", len(summary.Results))
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("Articles exported to")) {
+		t.Errorf("export message not written to stderr:\n%s", stderr.String())
+	}
+}
+
+func TestStrictSchemaFailsBeforeExport(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="ftp://example.com/1">Read</a></article>
+</body></html>`
+	dir := t.TempDir()
+	output := filepath.Join(dir, "articles")
+	updatesFile := filepath.Join(dir, "updates.json")
+	_, stderr, err := runCLI(t, page,
+		"-output", output,
+		"-updates-file", updatesFile,
+		"-strict-schema",
+	)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitSchemaViolation {
+		t.Fatalf("CLI error = %v, want exit code %d\n%s", err, exitSchemaViolation, stderr.String())
+	}
+
+	for _, path := range []string{output + ".csv", updatesFile} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s was written despite the schema violation (stat error = %v)", filepath.Base(path), err)
+		}
+	}
+}
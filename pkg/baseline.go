@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadBaseline reads the ID and URL of every article in a previous export.
+// The format is chosen from the file extension: .csv or .db (SQLite).
+func LoadBaseline(path string) ([]Article, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return loadCSVBaseline(path)
+	case ".db", ".sqlite", ".sqlite3":
+		return loadSQLiteBaseline(path)
+	default:
+		return nil, fmt.Errorf("unsupported baseline format: %s", path)
+	}
+}
+
+// NewArticlesSince returns the articles in fresh that don't appear in
+// baseline, matching on Flipboard's item ID when both sides have one, then
+// on ID and finally URL
+func NewArticlesSince(fresh, baseline []Article) []Article {
+	seenFlipboardIDs := make(map[string]bool, len(baseline))
+	seenIDs := make(map[string]bool, len(baseline))
+	seenURLs := make(map[string]bool, len(baseline))
+	for _, article := range baseline {
+		if article.FlipboardID != "" {
+			seenFlipboardIDs[article.FlipboardID] = true
+		}
+		if article.ID != "" {
+			seenIDs[article.ID] = true
+		}
+		if article.URL != "" {
+			seenURLs[article.URL] = true
+		}
+	}
+
+	result := make([]Article, 0, len(fresh))
+	for _, article := range fresh {
+		if article.FlipboardID != "" && seenFlipboardIDs[article.FlipboardID] {
+			continue
+		}
+		if article.ID != "" && seenIDs[article.ID] {
+			continue
+		}
+		if article.URL != "" && seenURLs[article.URL] {
+			continue
+		}
+		result = append(result, article)
+	}
+	return result
+}
+
+// loadCSVBaseline reads articles from a CSVExporter file, locating columns
+// by header name so exports from older versions still load
+func loadCSVBaseline(path string) ([]Article, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open baseline: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	articles := make([]Article, 0, len(records)-1)
+	for _, record := range records[1:] {
+		articles = append(articles, Article{
+			ID:          field(record, "ID"),
+			FlipboardID: field(record, "Flipboard ID"),
+			Title:       field(record, "Title"),
+			URL:         field(record, "URL"),
+		})
+	}
+	return articles, nil
+}
+
+// loadSQLiteBaseline reads articles from a SQLiteExporter database
+func loadSQLiteBaseline(path string) ([]Article, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open baseline: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open baseline database: %w", err)
+	}
+	defer db.Close()
+
+	// Databases from older versions may lack the ID columns
+	columns, err := sqliteColumns(db, "articles")
+	if err != nil {
+		return nil, err
+	}
+	optional := func(column string) string {
+		if columns[column] {
+			return fmt.Sprintf("COALESCE(%s, '')", column)
+		}
+		return "''"
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SELECT %s, %s, title, COALESCE(url, '') FROM articles",
+		optional("article_id"), optional("flipboard_id")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query baseline: %w", err)
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var article Article
+		if err := rows.Scan(&article.ID, &article.FlipboardID, &article.Title, &article.URL); err != nil {
+			return nil, fmt.Errorf("failed to read baseline row: %w", err)
+		}
+		articles = append(articles, article)
+	}
+	return articles, rows.Err()
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewArticlesSinceBaseline(t *testing.T) {
+	old := []Article{
+		{Title: "Old one", URL: "https://example.com/old-1"},
+		{Title: "Old two", URL: "https://example.com/old-2"},
+	}
+	fresh := []Article{
+		{Title: "Old one", URL: "https://example.com/old-1"},
+		{Title: "Brand new", URL: "https://example.com/new-1"},
+		{Title: "Old two retitled", URL: "https://example.com/old-2"},
+		{Title: "Another new", URL: "https://example.com/new-2"},
+	}
+	for _, set := range [][]Article{old, fresh} {
+		for i := range set {
+			set[i].ID = GenerateArticleID(set[i])
+		}
+	}
+
+	dir := t.TempDir()
+	baselines := map[string]interface{ Export([]Article) error }{
+		filepath.Join(dir, "baseline.csv"): NewCSVExporter(filepath.Join(dir, "baseline.csv")),
+		filepath.Join(dir, "baseline.db"):  NewSQLiteExporter(filepath.Join(dir, "baseline.db")),
+	}
+
+	for path, exporter := range baselines {
+		if err := exporter.Export(old); err != nil {
+			t.Fatalf("failed to write baseline %s: %v", path, err)
+		}
+
+		baseline, err := LoadBaseline(path)
+		if err != nil {
+			t.Fatalf("LoadBaseline(%s) error = %v", path, err)
+		}
+		if len(baseline) != len(old) {
+			t.Fatalf("LoadBaseline(%s) returned %d articles, want %d", path, len(baseline), len(old))
+		}
+
+		got := NewArticlesSince(fresh, baseline)
+		if len(got) != 2 || got[0].URL != "https://example.com/new-1" || got[1].URL != "https://example.com/new-2" {
+			t.Errorf("NewArticlesSince with %s baseline = %v, want only the two new articles", filepath.Ext(path), got)
+		}
+	}
+}
+
+func TestLoadBaselineUnsupported(t *testing.T) {
+	if _, err := LoadBaseline("articles.txt"); err == nil {
+		t.Error("Expected error for unsupported baseline format")
+	}
+}
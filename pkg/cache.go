@@ -0,0 +1,26 @@
+package pkg
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// cachedResponsePath returns where colly's CacheDir keeps the response for
+// rawURL: a SHA-1 of the URL, sharded by its first two hex digits
+func cachedResponsePath(cacheDir, rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	hash := hex.EncodeToString(sum[:])
+	return filepath.Join(cacheDir, hash[:2], hash)
+}
+
+// evictCached removes the cached response for rawURL, if any. Colly caches
+// every response below 500, so a 429 or a consent wall would otherwise be
+// replayed on retry.
+func evictCached(cacheDir, rawURL string) {
+	if cacheDir == "" {
+		return
+	}
+	os.Remove(cachedResponsePath(cacheDir, rawURL))
+}
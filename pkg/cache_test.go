@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheDirReusesResponses(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Cached</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	handler, requests := flakyHandler(http.StatusOK, 0, page)
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.CacheDir = t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		// A fresh scraper each time, like a re-run of the command
+		scraper := newFixtureScraper(t, config, handler)
+		articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag")
+		if err != nil {
+			t.Fatalf("run %d: ScrapeURL() error = %v", i+1, err)
+		}
+		if len(articles) != 1 || articles[0].Title != "Cached" {
+			t.Errorf("run %d: got articles %+v", i+1, articles)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1 with the second run served from the cache", n)
+	}
+}
+
+func TestCacheDirDoesNotReplayRateLimits(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Back</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	handler, requests := flakyHandler(http.StatusTooManyRequests, 1, page)
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 1
+	config.RetryBackoff = time.Millisecond
+	config.CacheDir = t.TempDir()
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v, want the retry to reach the server", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
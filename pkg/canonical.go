@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// CanonicalMagazineURL rewrites the many forms users paste for the same
+// magazine to one: https, no www. prefix, no default port, no trailing
+// slash and no fragment. URLs that can't be parsed are returned unchanged
+// for the scraper to reject.
+func CanonicalMagazineURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	if strings.EqualFold(u.Scheme, "http") {
+		u.Scheme = "https"
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host := strings.ToLower(u.Host)
+	if h, port, err := net.SplitHostPort(host); err == nil && (port == "443" || port == "80") {
+		host = h
+	}
+	u.Host = strings.TrimPrefix(host, "www.")
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// DefaultAllowedHosts are the hosts magazines may be scraped from when
+// ScraperConfig.AllowedHosts is empty
+var DefaultAllowedHosts = []string{"flipboard.com"}
+
+// ValidateURLs checks each of urls without any network requests: it must
+// parse, and its canonical form must be an https magazine path on one of
+// allowedHosts or their subdomains (DefaultAllowedHosts when none are
+// given). The result holds an error for each invalid URL, or nil, in the
+// order of urls.
+func ValidateURLs(urls []string, allowedHosts ...string) []error {
+	errs := make([]error, len(urls))
+	for i, rawURL := range urls {
+		errs[i] = validateURL(CanonicalMagazineURL(rawURL), allowedHosts)
+	}
+	return errs
+}
+
+// validateURL reports why rawURL can't be scraped, or nil when it can
+func validateURL(rawURL string, allowedHosts []string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return fmt.Errorf("malformed URL %q: %w", rawURL, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("malformed URL %q: missing scheme or host", rawURL)
+	}
+	allowedHosts = orDefaultHosts(allowedHosts)
+	if u.Scheme != "https" || !hostAllowed(u.Hostname(), allowedHosts) {
+		return fmt.Errorf("not a Flipboard URL: %s (allowed hosts: %s)", rawURL, strings.Join(allowedHosts, ", "))
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("no magazine path in %s", rawURL)
+	}
+	return nil
+}
+
+// orDefaultHosts returns allowedHosts, or DefaultAllowedHosts when empty
+func orDefaultHosts(allowedHosts []string) []string {
+	if len(allowedHosts) == 0 {
+		return DefaultAllowedHosts
+	}
+	return allowedHosts
+}
+
+// hostAllowed reports whether host is one of allowedHosts or a subdomain
+// of one
+func hostAllowed(host string, allowedHosts []string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// UniqueMagazineURLs canonicalizes urls and drops repeats, keeping the
+// first occurrence of each magazine in order
+func UniqueMagazineURLs(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	unique := make([]string, 0, len(urls))
+	for _, rawURL := range urls {
+		canonical := CanonicalMagazineURL(rawURL)
+		if seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		unique = append(unique, canonical)
+	}
+	return unique
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCanonicalMagazineURL(t *testing.T) {
+	want := "https://flipboard.com/@user/tech-abc123"
+	variants := []string{
+		"https://flipboard.com/@user/tech-abc123",
+		"https://flipboard.com/@user/tech-abc123/",
+		"https://www.flipboard.com/@user/tech-abc123",
+		"https://WWW.Flipboard.com/@user/tech-abc123/",
+		"https://flipboard.com:443/@user/tech-abc123",
+		"http://flipboard.com/@user/tech-abc123",
+		"https://flipboard.com/@user/tech-abc123#top",
+		"  https://flipboard.com/@user/tech-abc123  ",
+	}
+	for _, variant := range variants {
+		if got := CanonicalMagazineURL(variant); got != want {
+			t.Errorf("CanonicalMagazineURL(%q) = %q, want %q", variant, got, want)
+		}
+	}
+
+	if got := CanonicalMagazineURL("not a url"); got != "not a url" {
+		t.Errorf("CanonicalMagazineURL() changed an unparseable URL to %q", got)
+	}
+}
+
+func TestValidateURLs(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{"https://flipboard.com/@user/tech-abc123", ""},
+		{"http://www.flipboard.com/@user/tech-abc123/", ""},
+		{"https://example.com/@user/tech", "not a Flipboard URL"},
+		{"https://flipboard.com.evil.com/@user/tech", "not a Flipboard URL"},
+		{"ftp://flipboard.com/@user/tech", "not a Flipboard URL"},
+		{"https://flipboard.com/", "no magazine path"},
+		{"flipboard.com/@user/tech", "malformed URL"},
+		{"https://flipboard.com/%zz", "malformed URL"},
+	}
+	urls := make([]string, len(tests))
+	for i, tt := range tests {
+		urls[i] = tt.url
+	}
+	errs := ValidateURLs(urls)
+	if len(errs) != len(urls) {
+		t.Fatalf("got %d results for %d URLs", len(errs), len(urls))
+	}
+	for i, tt := range tests {
+		switch {
+		case tt.wantErr == "" && errs[i] != nil:
+			t.Errorf("%s: error = %v, want valid", tt.url, errs[i])
+		case tt.wantErr != "" && (errs[i] == nil || !strings.Contains(errs[i].Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want %q", tt.url, errs[i], tt.wantErr)
+		}
+	}
+}
+
+func TestAllowedHosts(t *testing.T) {
+	page := `<html><body><article class="item"><h3>Bonjour</h3><a href="https://example.com/1">Lire</a></article></body></html>`
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+	_, err := scraper.ScrapeURL(context.Background(), "https://www.flipboard.fr/@user/actu")
+	if err == nil || !strings.Contains(err.Error(), "allowed hosts: flipboard.com") {
+		t.Fatalf("ScrapeURL() error = %v, want the allowed hosts listed", err)
+	}
+
+	config.AllowedHosts = []string{"flipboard.com", "flipboard.fr"}
+	scraper = newFixtureScraper(t, config, fixtureHandler(page))
+	for _, url := range []string{"https://www.flipboard.com/@user/tech", "https://www.flipboard.fr/@user/actu", "https://fr.flipboard.fr/@user/actu"} {
+		articles, err := scraper.ScrapeURL(context.Background(), url)
+		if err != nil {
+			t.Errorf("ScrapeURL(%s) error = %v", url, err)
+		} else if len(articles) != 1 {
+			t.Errorf("ScrapeURL(%s) got %d articles, want 1", url, len(articles))
+		}
+	}
+
+	if err := validateURL("https://www.flipboard.com/@user/tech", nil); err != nil {
+		t.Errorf("validateURL() error = %v for a flipboard.com subdomain", err)
+	}
+	if errs := ValidateURLs([]string{"https://flipboard.fr/@user/actu"}, "flipboard.fr"); errs[0] != nil {
+		t.Errorf("ValidateURLs() error = %v with the host allowed", errs[0])
+	}
+}
+
+func TestUniqueMagazineURLsKeepsFirstOccurrenceOrder(t *testing.T) {
+	got := UniqueMagazineURLs([]string{
+		"https://flipboard.com/@user/b",
+		"https://flipboard.com/@user/a",
+		"https://flipboard.com/@user/b",
+		"https://www.flipboard.com/@user/c/",
+		"https://flipboard.com/@user/a/",
+		"https://flipboard.com/@user/c",
+	})
+	want := []string{"https://flipboard.com/@user/b", "https://flipboard.com/@user/a", "https://flipboard.com/@user/c"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("UniqueMagazineURLs() = %v, want %v", got, want)
+	}
+}
+
+func TestScrapeURLsDedupsMagazineVariants(t *testing.T) {
+	var requests int32
+	page := `<html><body><article class="item"><h3>Only</h3><a href="https://example.com/1">Read</a></article></body></html>`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(page))
+	})
+	scraper := newFixtureScraper(t, DefaultConfig(), handler)
+
+	articles, err := scraper.ScrapeURLs(context.Background(), []string{
+		"https://www.flipboard.com/@user/tech/",
+		"https://flipboard.com/@user/tech",
+		"https://flipboard.com:443/@user/tech/",
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("magazine was fetched %d times, want 1", requests)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1", len(articles))
+	}
+	if got := articles[0].SourceMagazineURL; got != "https://flipboard.com/@user/tech" {
+		t.Errorf("SourceMagazineURL = %q, want the canonical URL", got)
+	}
+}
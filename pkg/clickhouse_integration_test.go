@@ -0,0 +1,39 @@
+//go:build integration
+
+package pkg
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// Run with a ClickHouse server:
+//
+//	CLICKHOUSE_DSN=clickhouse://localhost:9000/default go test -tags integration ./pkg
+func TestClickHouseExporterIntegration(t *testing.T) {
+	dsn := os.Getenv("CLICKHOUSE_DSN")
+	if dsn == "" {
+		t.Skip("CLICKHOUSE_DSN not set")
+	}
+
+	articles := sampleArticles()
+	if err := NewClickHouseExporter(dsn).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	conn, err := openClickHouse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var count uint64
+	row := conn.QueryRow(context.Background(), "SELECT count() FROM articles WHERE url = ?", articles[0].URL)
+	if err := row.Scan(&count); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if count == 0 {
+		t.Errorf("article %q was not inserted", articles[0].URL)
+	}
+}
@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// acceptPhrases identify the button that accepts a consent form
+var acceptPhrases = []string{"accept", "agree", "allow", "consent"}
+
+// consentForm is a consent form found on an interstitial page
+type consentForm struct {
+	action string
+	method string
+	fields map[string]string
+}
+
+// consentWallError reports an interstitial, carrying its consent form when
+// one was found so the scraper can accept it and retry. It matches
+// ErrBlockedByInterstitial with errors.Is.
+type consentWallError struct {
+	form *consentForm
+}
+
+func (e *consentWallError) Error() string {
+	return ErrBlockedByInterstitial.Error()
+}
+
+func (e *consentWallError) Unwrap() error {
+	return ErrBlockedByInterstitial
+}
+
+// findConsentForm returns the first form inside a known consent wall,
+// with its hidden fields and the name and value of its accept button
+func findConsentForm(e *colly.HTMLElement) *consentForm {
+	for _, selector := range interstitialSelectors {
+		form := e.DOM.Find(selector).Find("form").First()
+		if form.Length() == 0 {
+			continue
+		}
+
+		action, _ := form.Attr("action")
+		method, _ := form.Attr("method")
+		consent := &consentForm{
+			action: e.Request.AbsoluteURL(action),
+			method: strings.ToUpper(method),
+			fields: make(map[string]string),
+		}
+		if consent.method == "" {
+			consent.method = "GET"
+		}
+
+		form.Find(`input[type="hidden"][name]`).Each(func(_ int, input *goquery.Selection) {
+			name, _ := input.Attr("name")
+			value, _ := input.Attr("value")
+			consent.fields[name] = value
+		})
+		form.Find(`button[name], input[type="submit"][name]`).EachWithBreak(func(_ int, button *goquery.Selection) bool {
+			name, _ := button.Attr("name")
+			value, _ := button.Attr("value")
+			label := strings.ToLower(button.Text() + " " + value)
+			for _, phrase := range acceptPhrases {
+				if strings.Contains(label, phrase) {
+					consent.fields[name] = value
+					return false
+				}
+			}
+			return true
+		})
+		return consent
+	}
+	return nil
+}
+
+// submitConsent sends form through the collector, so the consent cookies
+// it sets land in the jar shared by later requests
+func (s *MagazineScraper) submitConsent(ctx context.Context, form *consentForm) error {
+	if err := s.wait(ctx, form.action); err != nil {
+		return err
+	}
+
+	c := s.newCollector()
+	var submitErr error
+	c.OnError(func(r *colly.Response, err error) {
+		submitErr = fmt.Errorf("consent form returned status %d: %w", r.StatusCode, err)
+	})
+
+	var err error
+	if form.method == "POST" {
+		err = c.Post(form.action, form.fields)
+	} else {
+		err = c.Visit(withQuery(form.action, form.fields))
+	}
+	if err != nil {
+		return err
+	}
+	return submitErr
+}
+
+// withQuery adds fields to the query string of rawURL
+func withQuery(rawURL string, fields map[string]string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for name, value := range fields {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// consentWallHandler serves a consent wall until the form is posted with
+// the accept button, which sets a consent cookie
+func consentWallHandler(t *testing.T) http.Handler {
+	wall := `<html><body>
+<div class="cookie-consent">
+	<p>We value your privacy</p>
+	<form action="/consent" method="post">
+		<input type="hidden" name="csrf" value="tok123">
+		<button name="choice" value="reject">Reject all</button>
+		<button name="choice" value="accept">Accept all</button>
+	</form>
+</div>
+</body></html>`
+	page := `<html><body><article class="item"><h3>Behind the wall</h3><a href="https://example.com/1">Read</a></article></body></html>`
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/consent" {
+			if r.Method != http.MethodPost || r.FormValue("choice") != "accept" || r.FormValue("csrf") != "tok123" {
+				t.Errorf("unexpected consent submission: %s %v", r.Method, r.Form)
+				http.Error(w, "bad consent", http.StatusBadRequest)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "consent", Value: "yes", Path: "/"})
+			return
+		}
+		if cookie, err := r.Cookie("consent"); err == nil && cookie.Value == "yes" {
+			w.Write([]byte(page))
+			return
+		}
+		w.Write([]byte(wall))
+	})
+}
+
+func TestAcceptConsentRetries(t *testing.T) {
+	config := DefaultConfig()
+	config.AcceptConsent = true
+	config.RequestsPerSecond = 100
+	scraper := newFixtureScraper(t, config, consentWallHandler(t))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/walled")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 || articles[0].Title != "Behind the wall" {
+		t.Errorf("got %+v, want the article behind the consent wall", articles)
+	}
+}
+
+func TestConsentWallWithoutAcceptConsent(t *testing.T) {
+	scraper := newFixtureScraper(t, DefaultConfig(), consentWallHandler(t))
+
+	_, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/walled")
+	if !errors.Is(err, ErrBlockedByInterstitial) {
+		t.Errorf("ScrapeURL() error = %v, want ErrBlockedByInterstitial", err)
+	}
+}
+
+func TestAcceptConsentWithCacheDir(t *testing.T) {
+	config := DefaultConfig()
+	config.AcceptConsent = true
+	config.RequestsPerSecond = 100
+	config.CacheDir = t.TempDir()
+	scraper := newFixtureScraper(t, config, consentWallHandler(t))
+
+	// The refetch after accepting must not replay the cached wall
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/walled")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 || articles[0].Title != "Behind the wall" {
+		t.Errorf("got %+v, want the article behind the consent wall", articles)
+	}
+}
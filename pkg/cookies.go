@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in the domain field of a
+// cookies.txt file, as written by curl and browser export extensions
+const httpOnlyPrefix = "#HttpOnly_"
+
+// LoadCookiesFile reads cookies from a Netscape cookies.txt file, the format
+// written by curl -c and browser export extensions. Each line holds seven
+// tab-separated fields: domain, include subdomains, path, secure, expiry
+// (Unix seconds, 0 for a session cookie), name and value. Every cookie keeps
+// its domain, so one limited to its exact host is also sent to subdomains.
+func LoadCookiesFile(path string) ([]*http.Cookie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, httpOnlyPrefix)
+		if httpOnly {
+			text = strings.TrimPrefix(text, httpOnlyPrefix)
+		} else if strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("%s:%d: want 7 tab-separated fields, got %d", path, line, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid expiry %q", path, line, fields[4])
+		}
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Domain:   strings.TrimPrefix(fields[0], "."),
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cookies, nil
+}
+
+// setCookies stores cookies in the collector's jar, which its clones share.
+// A cookie is stored for its Domain, or for each allowed host when it has
+// none.
+func setCookies(c *colly.Collector, cookies []*http.Cookie, allowedHosts []string) error {
+	byHost := make(map[string][]*http.Cookie)
+	var hosts []string
+	for _, cookie := range cookies {
+		targets := []string{strings.TrimPrefix(cookie.Domain, ".")}
+		if cookie.Domain == "" {
+			targets = orDefaultHosts(allowedHosts)
+		}
+		for _, host := range targets {
+			if _, ok := byHost[host]; !ok {
+				hosts = append(hosts, host)
+			}
+			byHost[host] = append(byHost[host], cookie)
+		}
+	}
+	for _, host := range hosts {
+		if err := c.SetCookies("https://"+host+"/", byHost[host]); err != nil {
+			return fmt.Errorf("failed to set cookies for %s: %w", host, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCookiesSent(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]string)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if cookie, err := r.Cookie("session"); err == nil {
+			received[r.Host] = cookie.Value
+		}
+		mu.Unlock()
+		w.Write([]byte(`<html><head><meta property="og:description" content="From the page"></head><body>
+<article class="item"><h3>Title</h3><a href="https://example.com/1">Read</a></article></body></html>`))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.FetchOpenGraph = true
+	config.Cookies = []*http.Cookie{{Name: "session", Value: "abc123"}}
+	scraper := newFixtureScraper(t, config, handler)
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/gated"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := received["flipboard.com"]; got != "abc123" {
+		t.Errorf("session cookie = %q, want abc123", got)
+	}
+	if got, ok := received["example.com"]; ok {
+		t.Errorf("session cookie %q was sent to the article's site", got)
+	}
+}
+
+func TestLoadCookiesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	contents := "# Netscape HTTP Cookie File\n\n" +
+		".flipboard.com\tTRUE\t/\tTRUE\t0\tsession\tabc123\n" +
+		"#HttpOnly_flipboard.com\tFALSE\t/@user\tFALSE\t1893456000\ttoken\txyz\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cookies, err := LoadCookiesFile(path)
+	if err != nil {
+		t.Fatalf("LoadCookiesFile() error = %v", err)
+	}
+	if len(cookies) != 2 {
+		t.Fatalf("got %d cookies, want 2", len(cookies))
+	}
+	session := cookies[0]
+	if session.Name != "session" || session.Value != "abc123" || session.Domain != "flipboard.com" || !session.Secure || !session.Expires.IsZero() {
+		t.Errorf("session cookie = %+v", session)
+	}
+	token := cookies[1]
+	if token.Name != "token" || token.Path != "/@user" || !token.HttpOnly || token.Expires.Unix() != 1893456000 {
+		t.Errorf("token cookie = %+v", token)
+	}
+
+	if err := os.WriteFile(path, []byte("flipboard.com\tTRUE\t/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCookiesFile(path); err == nil {
+		t.Error("LoadCookiesFile() accepted a line with missing fields")
+	}
+}
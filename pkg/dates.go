@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DatePrecision records how Article.Date was determined
+type DatePrecision string
+
+const (
+	// DateUnknown means the item showed no date, so Date is zero
+	DateUnknown DatePrecision = ""
+	// DateApproximate means Date was derived from a relative label such as
+	// "3h ago", so it is only accurate to the label's unit
+	DateApproximate DatePrecision = "approximate"
+	// DateExact means Date came from an absolute timestamp, such as a
+	// <time datetime="..."> attribute
+	DateExact DatePrecision = "exact"
+)
+
+// absoluteDateLayouts are the ISO-8601 forms parseAbsoluteDate accepts;
+// layouts without a zone are read as UTC
+var absoluteDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// relativeAgePattern matches labels like "5m ago", "3 hours ago" or "2d"
+var relativeAgePattern = regexp.MustCompile(`^(\d+)\s*([a-z]+?)\.?(?:\s+ago)?$`)
+
+// ageUnits maps the unit spellings Flipboard uses to durations
+var ageUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "wk": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// parseRelativeAge converts a relative label such as "5m ago" into the time
+// it refers to, counting back from now. It reports false for labels it
+// doesn't recognize.
+func parseRelativeAge(label string, now time.Time) (time.Time, bool) {
+	label = strings.ToLower(cleanText(label))
+	switch label {
+	case "just now", "now":
+		return now, true
+	case "yesterday":
+		return now.Add(-24 * time.Hour), true
+	}
+
+	match := relativeAgePattern.FindStringSubmatch(label)
+	if match == nil {
+		return time.Time{}, false
+	}
+	unit, ok := ageUnits[match[2]]
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return now.Add(-time.Duration(n) * unit), true
+}
+
+// parseAbsoluteDate parses an ISO-8601 timestamp such as
+// "2024-03-10T09:30:00Z" or "2024-03-10"
+func parseAbsoluteDate(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range absoluteDateLayouts {
+		if date, err := time.Parse(layout, raw); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseArticleDate parses a date shown on an item, either an ISO-8601
+// timestamp or a relative label counted back from now. It returns the zero
+// time and false when raw is in neither form.
+func parseArticleDate(raw string, now time.Time) (time.Time, bool) {
+	if date, ok := parseAbsoluteDate(raw); ok {
+		return date, true
+	}
+	return parseRelativeAge(raw, now)
+}
@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRelativeAge(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		label string
+		want  time.Duration
+		ok    bool
+	}{
+		{"5m ago", 5 * time.Minute, true},
+		{"3h ago", 3 * time.Hour, true},
+		{"2d ago", 48 * time.Hour, true},
+		{"1w ago", 7 * 24 * time.Hour, true},
+		{"45s", 45 * time.Second, true},
+		{"10 min ago", 10 * time.Minute, true},
+		{"2 hours ago", 2 * time.Hour, true},
+		{"1 day ago", 24 * time.Hour, true},
+		{"  3H  AGO ", 3 * time.Hour, true},
+		{"just now", 0, true},
+		{"Yesterday", 24 * time.Hour, true},
+		{"", 0, false},
+		{"ago", 0, false},
+		{"5 fortnights ago", 0, false},
+		{"March 3, 2024", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRelativeAge(tt.label, now)
+		if ok != tt.ok {
+			t.Errorf("parseRelativeAge(%q) ok = %v, want %v", tt.label, ok, tt.ok)
+			continue
+		}
+		if ok && !got.Equal(now.Add(-tt.want)) {
+			t.Errorf("parseRelativeAge(%q) = %v, want %v", tt.label, got, now.Add(-tt.want))
+		}
+	}
+}
+
+func TestParseArticleDate(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		raw  string
+		want time.Time
+		ok   bool
+	}{
+		{"2024-03-08T09:30:00Z", time.Date(2024, 3, 8, 9, 30, 0, 0, time.UTC), true},
+		{"2024-03-08T09:30:00.250Z", time.Date(2024, 3, 8, 9, 30, 0, 250e6, time.UTC), true},
+		{"2024-03-08T09:30:00-05:00", time.Date(2024, 3, 8, 14, 30, 0, 0, time.UTC), true},
+		{"2024-03-08T09:30:00+0100", time.Date(2024, 3, 8, 8, 30, 0, 0, time.UTC), true},
+		{"2024-03-08T09:30:00", time.Date(2024, 3, 8, 9, 30, 0, 0, time.UTC), true},
+		{"2024-03-08T09:30", time.Date(2024, 3, 8, 9, 30, 0, 0, time.UTC), true},
+		{"2024-03-08 09:30:00", time.Date(2024, 3, 8, 9, 30, 0, 0, time.UTC), true},
+		{" 2024-03-08 ", time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), true},
+		{"3 hours ago", now.Add(-3 * time.Hour), true},
+		{"2d", now.Add(-48 * time.Hour), true},
+		{"yesterday", now.Add(-24 * time.Hour), true},
+		{"", time.Time{}, false},
+		{"March 3, 2024", time.Time{}, false},
+		{"2024-13-40", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseArticleDate(tt.raw, now)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseArticleDate(%q) = %v, %v, want %v, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExtractDateTimestamp(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Stamped</h3><a href="https://example.com/1">Read</a><time datetime="2024-03-08T09:30:00Z">3h ago</time></article>
+<article class="item"><h3>Labelled</h3><a href="https://example.com/2">Read</a><span class="timestamp">2024-03-07</span></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/stamped")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want 2", len(articles))
+	}
+
+	want := []time.Time{
+		time.Date(2024, 3, 8, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+	}
+	for i, article := range articles {
+		if !article.Date.Equal(want[i]) || article.DatePrecision != DateExact {
+			t.Errorf("%s: Date = %v (%q), want %v (%q)", article.Title, article.Date, article.DatePrecision, want[i], DateExact)
+		}
+	}
+}
+
+func TestExtractAgeLabel(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Recent</h3><a href="https://example.com/1">Read</a><span class="timestamp">3h ago</span></article>
+<article class="item"><h3>Undated</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	before := time.Now()
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/ages")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want 2", len(articles))
+	}
+
+	recent := articles[0]
+	if recent.DatePrecision != DateApproximate {
+		t.Errorf("DatePrecision = %q, want %q", recent.DatePrecision, DateApproximate)
+	}
+	if age := before.Sub(recent.Date); age < 3*time.Hour-time.Minute || age > 3*time.Hour+time.Minute {
+		t.Errorf("Date is %v old, want about 3h", age)
+	}
+	if undated := articles[1]; undated.DatePrecision != DateUnknown || !undated.Date.IsZero() {
+		t.Errorf("undated article: Date = %v, DatePrecision = %q, want a zero date", undated.Date, undated.DatePrecision)
+	}
+}
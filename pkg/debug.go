@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gocolly/colly/v2/debug"
+)
+
+// sensitiveParams are query parameters and event keys whose values are
+// masked in debug output
+var sensitiveParams = []string{"token", "key", "auth", "password", "secret", "signature", "sig", "cookie", "session"}
+
+// redacted replaces masked values in debug output
+const redacted = "REDACTED"
+
+// maskingDebugger sends colly debug events to a Logger's Debugf with
+// credentials masked
+type maskingDebugger struct {
+	logger Logger
+}
+
+// newDebugLogger returns a colly debugger logging through logger, or, when
+// logger is nil, through a standard logger writing to output (stderr when
+// nil)
+func newDebugLogger(logger Logger, output io.Writer) debug.Debugger {
+	if logger == nil {
+		if output == nil {
+			output = os.Stderr
+		}
+		logger = NewStdLogger(log.New(output, "", log.LstdFlags))
+	}
+	return maskingDebugger{logger: logger}
+}
+
+// Init satisfies debug.Debugger; there is nothing to set up
+func (d maskingDebugger) Init() error {
+	return nil
+}
+
+// Event masks sensitive values and logs e, its values sorted by key
+func (d maskingDebugger) Event(e *debug.Event) {
+	keys := make([]string, 0, len(e.Values))
+	for key := range e.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		value := e.Values[key]
+		switch {
+		case isSensitive(key):
+			value = redacted
+		case strings.Contains(value, "://"):
+			value = maskURL(value)
+		}
+		fields[i] = key + "=" + value
+	}
+	d.logger.Debugf("colly %s [request %d] %s", e.Type, e.RequestID, strings.Join(fields, " "))
+}
+
+// maskURL hides the password and sensitive query parameters of rawURL
+func maskURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.User != nil {
+		u.User = url.User(redacted)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for param := range query {
+			if isSensitive(param) {
+				query.Set(param, redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// isSensitive reports whether name looks like it holds a credential
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range sensitiveParams {
+		if strings.Contains(name, param) {
+			return true
+		}
+	}
+	return false
+}
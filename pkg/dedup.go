@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Deduplicate drops articles matching an earlier article, keeping the first
+// occurrence. Articles match on Flipboard's item ID, which survives a
+// change of article URL, or else on their URL once normalized: the same
+// story flipped into several magazines often differs only by tracking
+// query parameters or a trailing slash. Articles with neither are always
+// kept.
+func Deduplicate(articles []Article) []Article {
+	seenFlipboardIDs := make(map[string]bool, len(articles))
+	seenURLs := make(map[string]bool, len(articles))
+	unique := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		if article.FlipboardID != "" && seenFlipboardIDs[article.FlipboardID] {
+			continue
+		}
+		var key string
+		if article.URL != "" {
+			key = normalizeArticleURL(article.URL)
+			if seenURLs[key] {
+				continue
+			}
+		}
+		if article.FlipboardID != "" {
+			seenFlipboardIDs[article.FlipboardID] = true
+		}
+		if key != "" {
+			seenURLs[key] = true
+		}
+		unique = append(unique, article)
+	}
+	return unique
+}
+
+// normalizeArticleURL reduces an article URL to the parts that identify the
+// article: no query string, fragment or trailing slash, and a lowercase
+// scheme and host. URLs that can't be parsed are only trimmed.
+func normalizeArticleURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return strings.TrimRight(strings.TrimSpace(rawURL), "/")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
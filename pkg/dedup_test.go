@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestDeduplicate(t *testing.T) {
+	tests := []struct {
+		name string
+		urls []string
+		want []string
+	}{
+		{
+			name: "tracking parameters",
+			urls: []string{
+				"https://example.com/story?utm_source=flipboard",
+				"https://example.com/story?utm_source=twitter&utm_medium=social",
+				"https://example.com/story",
+			},
+			want: []string{"https://example.com/story?utm_source=flipboard"},
+		},
+		{
+			name: "trailing slash and fragment",
+			urls: []string{"https://example.com/a/", "https://example.com/a#comments", "https://EXAMPLE.com/a"},
+			want: []string{"https://example.com/a/"},
+		},
+		{
+			name: "different articles",
+			urls: []string{"https://example.com/a", "https://example.com/b", "https://other.example/a"},
+			want: []string{"https://example.com/a", "https://example.com/b", "https://other.example/a"},
+		},
+		{
+			name: "articles without URLs are kept",
+			urls: []string{"", "", "https://example.com/a"},
+			want: []string{"", "", "https://example.com/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var articles []Article
+			for _, url := range tt.urls {
+				articles = append(articles, Article{URL: url})
+			}
+			got := Deduplicate(articles)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Deduplicate() kept %d articles, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, article := range got {
+				if article.URL != tt.want[i] {
+					t.Errorf("article %d URL = %q, want %q", i, article.URL, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDeduplicateByFlipboardID(t *testing.T) {
+	articles := []Article{
+		{FlipboardID: "item-1", Title: "Original", URL: "https://example.com/story"},
+		{FlipboardID: "item-1", Title: "Moved", URL: "https://example.com/2024/story"},
+		{FlipboardID: "item-2", Title: "Other", URL: "https://example.com/other"},
+		{Title: "Same URL", URL: "https://example.com/other?utm_source=flipboard"},
+	}
+	got := Deduplicate(articles)
+	if len(got) != 2 || got[0].Title != "Original" || got[1].Title != "Other" {
+		t.Errorf("Deduplicate() = %+v, want Original and Other", got)
+	}
+}
+
+func TestScrapeURLsDedup(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+<article class="item"><h3>Shared</h3><a href="https://example.com/shared?utm_source=` + r.URL.Path + `">Read</a></article>
+<article class="item"><h3>Own</h3><a href="https://example.com` + r.URL.Path + `">Read</a></article>
+</body></html>`))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.Dedup = true
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, err := scraper.ScrapeURLs(context.Background(), []string{
+		"https://flipboard.com/@user/one",
+		"https://flipboard.com/@user/two",
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Errorf("got %d articles, want the shared one once and each magazine's own: %+v", len(articles), articles)
+	}
+}
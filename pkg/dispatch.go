@@ -0,0 +1,148 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what happens when the OnArticle buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes extraction wait for the consumer to catch up
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered article to make room,
+	// so extraction never waits on a slow consumer
+	OverflowDropOldest
+)
+
+// defaultArticleBuffer is the OnArticle buffer size used when none is set
+const defaultArticleBuffer = 64
+
+// articleDispatcher hands extracted articles to a callback on its own
+// goroutine, so a slow callback doesn't run on colly's callback thread
+type articleDispatcher struct {
+	// mu guards closed; a cancelled scrape can still be extracting
+	// articles after the dispatcher has been closed
+	mu       sync.RWMutex
+	closed   bool
+	articles chan Article
+	policy   OverflowPolicy
+	// closing is closed when close starts, releasing sends blocked on a
+	// full buffer so close can take mu
+	closing chan struct{}
+	// abandon is closed when the drain grace period runs out; the worker
+	// then discards whatever is still queued
+	abandon chan struct{}
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// newArticleDispatcher starts a worker calling fn for every article sent.
+// It returns nil when fn is nil; a nil dispatcher ignores every call.
+func newArticleDispatcher(fn func(Article), buffer int, policy OverflowPolicy) *articleDispatcher {
+	if fn == nil {
+		return nil
+	}
+	if buffer < 1 {
+		buffer = defaultArticleBuffer
+	}
+
+	d := &articleDispatcher{
+		articles: make(chan Article, buffer),
+		policy:   policy,
+		closing:  make(chan struct{}),
+		abandon:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(d.done)
+		for article := range d.articles {
+			select {
+			case <-d.abandon:
+				d.dropped.Add(1)
+				continue
+			default:
+			}
+			fn(article)
+		}
+	}()
+	return d
+}
+
+// send queues article for the callback, applying the overflow policy when
+// the buffer is full. A send blocked on a full buffer gives up, dropping
+// the article, once ctx is cancelled or the dispatcher starts closing.
+func (d *articleDispatcher) send(ctx context.Context, article Article) {
+	if d == nil {
+		return
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	if d.policy != OverflowDropOldest {
+		select {
+		case d.articles <- article:
+			return
+		default:
+		}
+		select {
+		case d.articles <- article:
+		case <-ctx.Done():
+			d.dropped.Add(1)
+		case <-d.closing:
+			d.dropped.Add(1)
+		}
+		return
+	}
+
+	for {
+		select {
+		case d.articles <- article:
+			return
+		default:
+		}
+		// Full: drop the oldest article, unless the worker just took it
+		select {
+		case <-d.articles:
+			d.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// close waits for the callback to finish the queued articles and returns
+// how many articles were dropped. Later sends are ignored, and sends blocked
+// on a full buffer give up. A positive grace bounds the wait: once it runs
+// out the rest of the queue is discarded and close returns, leaving only
+// the callback already in progress to finish on its own.
+func (d *articleDispatcher) close(grace time.Duration) int64 {
+	if d == nil {
+		return 0
+	}
+	close(d.closing)
+	d.mu.Lock()
+	d.closed = true
+	close(d.articles)
+	d.mu.Unlock()
+
+	if grace <= 0 {
+		<-d.done
+		return d.dropped.Load()
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-d.done:
+	case <-timer.C:
+		close(d.abandon)
+		for range d.articles {
+			d.dropped.Add(1)
+		}
+	}
+	return d.dropped.Load()
+}
@@ -0,0 +1,252 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowConsumer blocks on the first article until released
+type slowConsumer struct {
+	mu       sync.Mutex
+	titles   []string
+	started  chan struct{}
+	release  chan struct{}
+	waitOnce sync.Once
+}
+
+func newSlowConsumer() *slowConsumer {
+	return &slowConsumer{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (c *slowConsumer) consume(article Article) {
+	c.waitOnce.Do(func() {
+		close(c.started)
+		<-c.release
+	})
+	c.mu.Lock()
+	c.titles = append(c.titles, article.Title)
+	c.mu.Unlock()
+}
+
+func TestArticleDispatcherDropOldest(t *testing.T) {
+	consumer := newSlowConsumer()
+	d := newArticleDispatcher(consumer.consume, 2, OverflowDropOldest)
+
+	d.send(context.Background(), Article{Title: "0"})
+	<-consumer.started
+	// The consumer is stuck on "0"; sends must not block on it
+	for i := 1; i <= 5; i++ {
+		d.send(context.Background(), Article{Title: fmt.Sprint(i)})
+	}
+	close(consumer.release)
+
+	if dropped := d.close(0); dropped != 3 {
+		t.Errorf("dropped %d articles, want 3", dropped)
+	}
+	want := []string{"0", "4", "5"}
+	if fmt.Sprint(consumer.titles) != fmt.Sprint(want) {
+		t.Errorf("consumer got %v, want %v", consumer.titles, want)
+	}
+}
+
+func TestArticleDispatcherBlock(t *testing.T) {
+	consumer := newSlowConsumer()
+	d := newArticleDispatcher(consumer.consume, 1, OverflowBlock)
+
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			d.send(context.Background(), Article{Title: fmt.Sprint(i)})
+		}
+		close(sent)
+	}()
+
+	<-consumer.started
+	select {
+	case <-sent:
+		t.Fatal("sends completed while the consumer was stalled and the buffer was full")
+	default:
+	}
+	close(consumer.release)
+	<-sent
+
+	if dropped := d.close(0); dropped != 0 {
+		t.Errorf("dropped %d articles, want 0", dropped)
+	}
+	want := []string{"0", "1", "2", "3", "4"}
+	if fmt.Sprint(consumer.titles) != fmt.Sprint(want) {
+		t.Errorf("consumer got %v, want %v", consumer.titles, want)
+	}
+}
+
+func TestArticleDispatcherDrainGrace(t *testing.T) {
+	before := runtime.NumGoroutine()
+	consumer := newSlowConsumer()
+	d := newArticleDispatcher(consumer.consume, 1, OverflowBlock)
+
+	d.send(context.Background(), Article{Title: "0"})
+	<-consumer.started
+	d.send(context.Background(), Article{Title: "1"})
+	// The buffer is full, so this send blocks until close releases it
+	blocked := make(chan struct{})
+	go func() {
+		d.send(context.Background(), Article{Title: "2"})
+		close(blocked)
+	}()
+
+	start := time.Now()
+	dropped := d.close(20 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("close took %v with a stalled consumer, want about the grace period", elapsed)
+	}
+	<-blocked
+	// "1" was discarded from the queue; "2" is dropped too if its send had
+	// blocked before close started, and ignored otherwise
+	if dropped < 1 || dropped > 2 {
+		t.Errorf("dropped %d articles, want 1 or 2", dropped)
+	}
+	// Sends after close are ignored rather than panicking
+	d.send(context.Background(), Article{Title: "3"})
+
+	close(consumer.release)
+	<-d.done
+	if fmt.Sprint(consumer.titles) != "[0]" {
+		t.Errorf("consumer got %v, want [0]", consumer.titles)
+	}
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines running, want at most %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestArticleDispatcherSendCancelled(t *testing.T) {
+	consumer := newSlowConsumer()
+	d := newArticleDispatcher(consumer.consume, 1, OverflowBlock)
+	defer func() {
+		close(consumer.release)
+		d.close(0)
+	}()
+
+	d.send(context.Background(), Article{Title: "0"})
+	<-consumer.started
+	d.send(context.Background(), Article{Title: "1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sent := make(chan struct{})
+	go func() {
+		d.send(ctx, Article{Title: "2"})
+		close(sent)
+	}()
+	cancel()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("send stayed blocked after its context was cancelled")
+	}
+}
+
+func TestScrapeURLsCancelledMidStream(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+<article class="item"><h3>Three</h3><a href="https://example.com/3">Read</a></article>
+<article class="item"><h3>Four</h3><a href="https://example.com/4">Read</a></article>
+</body></html>`
+	consumer := newSlowConsumer()
+	config := DefaultConfig()
+	config.OnArticle = consumer.consume
+	config.ArticleBuffer = 1
+	config.ArticleDrainTimeout = 20 * time.Millisecond
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-consumer.started
+		cancel()
+	}()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scraper.ScrapeURLs(ctx, []string{"https://flipboard.com/@user/m"})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ScrapeURLs didn't return after cancellation with OnArticle stalled")
+	}
+	close(consumer.release)
+}
+
+func TestOnArticleReceivesEveryArticle(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	var got []string
+	config := DefaultConfig()
+	config.OnArticle = func(a Article) { got = append(got, a.Title) }
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	if _, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/m"}); err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+	if fmt.Sprint(got) != "[One Two]" {
+		t.Errorf("OnArticle got %v, want [One Two]", got)
+	}
+}
+
+func TestScrapeURLsStream(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+<article class="item"><h3>Three</h3><a href="https://example.com/3">Read</a></article>
+</body></html>`
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	calls := 0
+	err := scraper.ScrapeURLsStream(context.Background(), []string{
+		"https://flipboard.com/@user/a",
+		"https://flipboard.com/@user/b",
+	}, func(Article) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLsStream() error = %v", err)
+	}
+	if calls != 6 {
+		t.Errorf("callback called %d times, want 6", calls)
+	}
+}
+
+func TestScrapeURLsStreamStopsOnError(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+<article class="item"><h3>Three</h3><a href="https://example.com/3">Read</a></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	stop := errors.New("seen enough")
+	calls := 0
+	err := scraper.ScrapeURLsStream(context.Background(), []string{"https://flipboard.com/@user/a"}, func(Article) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf("ScrapeURLsStream() error = %v, want the callback's error", err)
+	}
+	if calls != 1 {
+		t.Errorf("callback called %d times after returning an error, want 1", calls)
+	}
+}
@@ -0,0 +1,1235 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/net/html"
+)
+
+func sampleArticles() []Article {
+	return []Article{
+		{
+			Title:   "Go 1.22 Released",
+			URL:     "https://go.dev/blog/go1.22",
+			Summary: "Range over integers & more",
+			Date:    time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Title:   "<script>alert('xss')</script>",
+			URL:     "https://example.com/post?a=1&b=2",
+			Summary: "Tricky <b>markup</b>",
+			Date:    time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC),
+		},
+	}
+}
+
+// readCSVColumn returns the values of the named column in a CSV export
+func readCSVColumn(t *testing.T, path, column string) []string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	index := -1
+	for i, name := range rows[0] {
+		if name == column {
+			index = i
+		}
+	}
+	if index < 0 {
+		t.Fatalf("%s has no %q column", path, column)
+	}
+
+	var values []string
+	for _, row := range rows[1:] {
+		values = append(values, row[index])
+	}
+	return values
+}
+
+func TestJSONExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "articles.json")
+	articles := sampleArticles()
+	if err := NewJSONExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\n  {\n") {
+		t.Errorf("expected indented JSON, got:\n%s", data)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("export is not a JSON array: %v", err)
+	}
+	if len(decoded) != len(articles) {
+		t.Fatalf("got %d articles, want %d", len(decoded), len(articles))
+	}
+	if decoded[0]["title"] != articles[0].Title || decoded[0]["url"] != articles[0].URL {
+		t.Errorf("first article = %v", decoded[0])
+	}
+	date, err := time.Parse(time.RFC3339, decoded[0]["date"].(string))
+	if err != nil || !date.Equal(articles[0].Date) {
+		t.Errorf("date = %v, want %s in RFC 3339 format", decoded[0]["date"], articles[0].Date)
+	}
+}
+
+func TestJSONExporterEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "articles.json")
+	if err := NewJSONExporter(path).Export(nil); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "[]" {
+		t.Errorf("empty export = %q, want []", got)
+	}
+}
+
+func TestNDJSONExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "articles.ndjson")
+	articles := sampleArticles()
+	if err := NewNDJSONExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != len(articles) {
+		t.Fatalf("got %d lines, want one per article:\n%s", len(lines), data)
+	}
+	for i, line := range lines {
+		var article Article
+		if err := json.Unmarshal([]byte(line), &article); err != nil {
+			t.Fatalf("line %d is not an article: %v", i+1, err)
+		}
+		if article.Title != articles[i].Title || !article.Date.Equal(articles[i].Date) {
+			t.Errorf("line %d = %+v, want %+v", i+1, article, articles[i])
+		}
+	}
+}
+
+func TestHTMLExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	exporter := NewHTMLExporter(path, "My Magazine")
+	if err := exporter.Export(sampleArticles()); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if strings.Contains(string(data), "<script>") {
+		t.Error("HTML report contains unescaped article content")
+	}
+
+	doc, err := html.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("report is not valid HTML: %v", err)
+	}
+
+	var links []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, attr := range n.Attr {
+				if attr.Key == "href" {
+					links = append(links, attr.Val)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	want := []string{"https://go.dev/blog/go1.22", "https://example.com/post?a=1&b=2"}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d", len(links), len(want))
+	}
+	for i, link := range want {
+		if links[i] != link {
+			t.Errorf("link %d = %q, want %q", i, links[i], link)
+		}
+	}
+}
+
+func TestSQLiteExporterConnectionLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "articles.db")
+
+	db, err := NewSQLiteExporter(path).openDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Stats().MaxOpenConnections; got != defaultSQLiteMaxOpenConns {
+		t.Errorf("default MaxOpenConnections = %d, want %d", got, defaultSQLiteMaxOpenConns)
+	}
+	db.Close()
+
+	db, err = NewSQLiteExporter(path, WithMaxOpenConns(3), WithMaxIdleConns(1)).openDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if got := db.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("MaxOpenConnections = %d, want 3", got)
+	}
+
+	// Release two connections; only one may stay idle
+	ctx := context.Background()
+	first, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Close()
+	second.Close()
+	if got := db.Stats().Idle; got != 1 {
+		t.Errorf("idle connections = %d, want 1", got)
+	}
+
+	if err := NewSQLiteExporter(path, WithMaxOpenConns(3), WithMaxIdleConns(1)).Export(sampleArticles()); err != nil {
+		t.Errorf("Export failed with custom limits: %v", err)
+	}
+}
+
+func TestSQLiteExporterArticleIDKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "articles.db")
+	articles := sampleArticles()
+	for i := range articles {
+		articles[i].ID = GenerateArticleID(articles[i])
+	}
+
+	exporter := NewSQLiteExporter(path, WithArticleIDKey())
+	for run := 0; run < 2; run++ {
+		if err := exporter.Export(articles); err != nil {
+			t.Fatalf("Export() run %d error = %v", run, err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&count); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if count != len(articles) {
+		t.Errorf("got %d rows after two exports, want %d", count, len(articles))
+	}
+
+	var id string
+	if err := db.QueryRow("SELECT article_id FROM articles WHERE url = ?", articles[0].URL).Scan(&id); err != nil {
+		t.Fatalf("failed to query article: %v", err)
+	}
+	if id != articles[0].ID {
+		t.Errorf("article_id = %q, want %q", id, articles[0].ID)
+	}
+}
+
+func TestSQLiteExporterIdempotent(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		opts      []SQLiteOption
+		wantTitle string
+	}{
+		{"ignore", nil, "Go 1.22 Released"},
+		{"replace", []SQLiteOption{WithReplaceExisting()}, "Updated"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "articles.db")
+			articles := append(sampleArticles(), Article{Title: "No link"})
+			exporter := NewSQLiteExporter(path, tt.opts...)
+			if err := exporter.Export(articles); err != nil {
+				t.Fatalf("first Export() error = %v", err)
+			}
+			articles[0].Title = "Updated"
+			if err := exporter.Export(articles); err != nil {
+				t.Fatalf("second Export() error = %v", err)
+			}
+
+			db, err := sql.Open("sqlite3", path)
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			defer db.Close()
+
+			// Articles without a URL can't be matched, so they are added again
+			var count int
+			if err := db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&count); err != nil {
+				t.Fatalf("failed to count rows: %v", err)
+			}
+			if want := len(articles) + 1; count != want {
+				t.Errorf("got %d rows after two exports, want %d", count, want)
+			}
+
+			var title string
+			if err := db.QueryRow("SELECT title FROM articles WHERE url = ?", articles[0].URL).Scan(&title); err != nil {
+				t.Fatalf("failed to query article: %v", err)
+			}
+			if title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestICSExporter(t *testing.T) {
+	articles := append(sampleArticles(), Article{Title: "Undated", URL: "https://example.com/undated"})
+	articles[0].Title = "Go 1.22; faster, better"
+
+	path := filepath.Join(t.TempDir(), "articles.ics")
+	if err := NewICSExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read ICS file: %v", err)
+	}
+	content := string(data)
+	if !strings.HasSuffix(content, "\r\n") {
+		t.Error("ICS content lines must end with CRLF")
+	}
+
+	// Unfold continuation lines, then check structure
+	content = strings.ReplaceAll(content, "\r\n ", "")
+	lines := strings.Split(strings.TrimSuffix(content, "\r\n"), "\r\n")
+	if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" {
+		t.Fatalf("calendar not wrapped in VCALENDAR: first %q last %q", lines[0], lines[len(lines)-1])
+	}
+
+	var (
+		depth     int
+		events    int
+		summaries []string
+	)
+	for _, line := range lines {
+		if len(line) > 0 && !strings.Contains(line, ":") {
+			t.Errorf("malformed content line %q", line)
+		}
+		switch {
+		case strings.HasPrefix(line, "BEGIN:"):
+			depth++
+			if line == "BEGIN:VEVENT" {
+				events++
+			}
+		case strings.HasPrefix(line, "END:"):
+			depth--
+		case strings.HasPrefix(line, "SUMMARY:"):
+			summaries = append(summaries, strings.TrimPrefix(line, "SUMMARY:"))
+		case strings.HasPrefix(line, "DTSTART:"):
+			if _, err := time.Parse("20060102T150405Z", strings.TrimPrefix(line, "DTSTART:")); err != nil {
+				t.Errorf("invalid DTSTART %q: %v", line, err)
+			}
+		}
+	}
+	if depth != 0 {
+		t.Errorf("unbalanced BEGIN/END blocks (depth %d)", depth)
+	}
+	if events != 2 {
+		t.Fatalf("got %d events, want 2 (undated articles skipped)", events)
+	}
+	if summaries[0] != `Go 1.22\; faster\, better` {
+		t.Errorf("SUMMARY = %q, want escaped title", summaries[0])
+	}
+}
+
+func TestICSExporterKeepsDatesWithoutPrecision(t *testing.T) {
+	// Articles built by callers or loaded from older exports have a Date
+	// but no DatePrecision
+	articles := []Article{{
+		Title: "Built by hand",
+		URL:   "https://example.com/by-hand",
+		Date:  time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}}
+
+	path := filepath.Join(t.TempDir(), "articles.ics")
+	if err := NewICSExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read ICS file: %v", err)
+	}
+	if !strings.Contains(string(data), "DTSTART:20240301T120000Z") {
+		t.Errorf("dated article without a precision was skipped:\n%s", data)
+	}
+}
+
+func TestTransformSentimentReachesExports(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Great news</h3><a href="https://example.com/good">Read</a></article>
+</body></html>`
+	config := DefaultConfig()
+	config.Transform = func(a Article) Article {
+		a.Sentiment = "positive"
+		return a
+	}
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/enriched")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 || articles[0].Sentiment != "positive" {
+		t.Fatalf("Transform did not set Sentiment: %+v", articles)
+	}
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "articles.csv")
+	if err := NewCSVExporter(csvPath).Export(articles); err != nil {
+		t.Fatalf("CSV Export() error = %v", err)
+	}
+	if got := readCSVColumn(t, csvPath, "Sentiment"); len(got) != 1 || got[0] != "positive" {
+		t.Errorf("CSV Sentiment column = %v, want [positive]", got)
+	}
+
+	dbPath := filepath.Join(dir, "articles.db")
+	if err := NewSQLiteExporter(dbPath).Export(articles); err != nil {
+		t.Fatalf("SQLite Export() error = %v", err)
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var sentiment string
+	if err := db.QueryRow("SELECT sentiment FROM articles").Scan(&sentiment); err != nil {
+		t.Fatalf("failed to read sentiment: %v", err)
+	}
+	if sentiment != "positive" {
+		t.Errorf("SQLite sentiment = %q, want positive", sentiment)
+	}
+}
+
+func TestExportersRecordMagazine(t *testing.T) {
+	articles := sampleArticles()[:1]
+	articles[0].Magazine = "Tech News"
+	articles[0].SourceMagazineURL = "https://flipboard.com/@user/tech"
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "articles.csv")
+	if err := NewCSVExporter(csvPath).Export(articles); err != nil {
+		t.Fatalf("CSV Export() error = %v", err)
+	}
+	if got := readCSVColumn(t, csvPath, "Source Magazine URL"); len(got) != 1 || got[0] != articles[0].SourceMagazineURL {
+		t.Errorf("CSV Source Magazine URL column = %v, want the magazine URL", got)
+	}
+
+	dbPath := filepath.Join(dir, "articles.db")
+	if err := NewSQLiteExporter(dbPath).Export(articles); err != nil {
+		t.Fatalf("SQLite Export() error = %v", err)
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var magazine, source string
+	if err := db.QueryRow("SELECT magazine, source_magazine_url FROM articles").Scan(&magazine, &source); err != nil {
+		t.Fatalf("failed to read magazine: %v", err)
+	}
+	if magazine != articles[0].Magazine || source != articles[0].SourceMagazineURL {
+		t.Errorf("SQLite magazine = %q, %q, want the name and URL", magazine, source)
+	}
+
+	tests := []struct {
+		file     string
+		exporter Exporter
+		want     string
+	}{
+		{"articles.md", NewMarkdownExporter(filepath.Join(dir, "articles.md")), "*February 6, 2024, from Tech News*"},
+		{"articles.rss", NewRSSExporter(filepath.Join(dir, "articles.rss"), "Tech"), `<source url="https://flipboard.com/@user/tech">Tech News</source>`},
+		{"articles.html", NewHTMLExporter(filepath.Join(dir, "articles.html"), "Tech"), `From <a href="https://flipboard.com/@user/tech">Tech News</a>`},
+		{"articles.ics", NewICSExporter(filepath.Join(dir, "articles.ics")), "CATEGORIES:Tech News"},
+	}
+	for _, tt := range tests {
+		if err := tt.exporter.Export(articles); err != nil {
+			t.Fatalf("%s: Export() error = %v", tt.file, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("%s doesn't record the magazine, want %q in\n%s", tt.file, tt.want, data)
+		}
+	}
+}
+
+func TestExportersRecordSentiment(t *testing.T) {
+	articles := sampleArticles()[:1]
+	articles[0].Sentiment = "positive"
+	articles[0].Tags = []string{"Go"}
+	dir := t.TempDir()
+
+	markdownPath := filepath.Join(dir, "articles.md")
+	if err := NewMarkdownExporter(markdownPath).Export(articles); err != nil {
+		t.Fatalf("Markdown Export() error = %v", err)
+	}
+	data, err := os.ReadFile(markdownPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\nSentiment: positive\n") {
+		t.Errorf("Markdown export has no sentiment:\n%s", data)
+	}
+
+	rssPath := filepath.Join(dir, "articles.rss")
+	if err := NewRSSExporter(rssPath, "Tech").Export(articles); err != nil {
+		t.Fatalf("RSS Export() error = %v", err)
+	}
+	data, err = os.ReadFile(rssPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed struct {
+		Items []struct {
+			Categories []struct {
+				Domain string `xml:"domain,attr"`
+				Value  string `xml:",chardata"`
+			} `xml:"category"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("feed is not well-formed XML: %v", err)
+	}
+	if len(feed.Items) != 1 {
+		t.Fatalf("feed has %d items, want 1", len(feed.Items))
+	}
+	categories := feed.Items[0].Categories
+	if len(categories) != 2 || categories[0].Value != "Go" || categories[0].Domain != "" ||
+		categories[1].Value != "positive" || categories[1].Domain != "sentiment" {
+		t.Errorf("categories = %+v, want the tag and the sentiment", categories)
+	}
+}
+
+func TestNotifyExporter(t *testing.T) {
+	tests := []struct {
+		format NotifyFormat
+		field  string
+	}{
+		{NotifySlack, "text"},
+		{NotifyDiscord, "content"},
+	}
+
+	for _, tt := range tests {
+		var payload map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("%s: failed to decode payload: %v", tt.format, err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		exporter := NewNotifyExporter(server.URL, WithNotifyFormat(tt.format))
+		if err := exporter.Export(sampleArticles()); err != nil {
+			t.Fatalf("%s: Export failed: %v", tt.format, err)
+		}
+		server.Close()
+
+		message := payload[tt.field]
+		if !strings.Contains(message, "2 articles scraped") {
+			t.Errorf("%s: message missing article count: %q", tt.format, message)
+		}
+		if !strings.Contains(message, "Go 1.22 Released") {
+			t.Errorf("%s: message missing top title: %q", tt.format, message)
+		}
+	}
+}
+
+func TestNotifyExporterTruncates(t *testing.T) {
+	articles := make([]Article, 3)
+	for i := range articles {
+		articles[i].Title = strings.Repeat("long title ", 300)
+	}
+
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	if err := NewNotifyExporter(server.URL, WithNotifyFormat(NotifyDiscord)).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if n := utf8.RuneCountInString(payload["content"]); n > discordMessageLimit {
+		t.Errorf("Discord message has %d characters, want at most %d", n, discordMessageLimit)
+	}
+}
+
+func TestNotifyExporterWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if err := NewNotifyExporter(server.URL).Export(sampleArticles()); err == nil {
+		t.Error("Expected error when the webhook rejects the message")
+	}
+}
+
+func TestCSVExporterInvalidUTF8(t *testing.T) {
+	articles := []Article{{
+		Title:   "Caf\xe9 culture",
+		URL:     "https://example.com/cafe",
+		Summary: "ok",
+	}}
+
+	csvPath := filepath.Join(t.TempDir(), "articles.csv")
+	if err := NewCSVExporter(csvPath).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(data) {
+		t.Error("CSV export contains invalid UTF-8")
+	}
+	if got := readCSVColumn(t, csvPath, "Title"); got[0] != "Caf� culture" {
+		t.Errorf("Title = %q, want invalid byte replaced with U+FFFD", got[0])
+	}
+
+	err = NewCSVExporter(csvPath, WithStrictUTF8()).Export(articles)
+	if err == nil || !strings.Contains(err.Error(), "Title") {
+		t.Errorf("Expected strict export to reject the Title, got %v", err)
+	}
+}
+
+// countingExporter records how many exports run at the same time
+type countingExporter struct {
+	active, peak *int32
+	err          error
+}
+
+func (e countingExporter) Export(articles []Article) error {
+	n := atomic.AddInt32(e.active, 1)
+	defer atomic.AddInt32(e.active, -1)
+	for {
+		peak := atomic.LoadInt32(e.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(e.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return e.err
+}
+
+func TestMultiExporterConcurrency(t *testing.T) {
+	var active, peak int32
+	var sinks []Exporter
+	for i := 0; i < 6; i++ {
+		sinks = append(sinks, countingExporter{active: &active, peak: &peak})
+	}
+
+	exporter := NewMultiExporter(sinks...)
+	exporter.ExportConcurrency = 2
+	if err := exporter.Export(sampleArticles()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("peak concurrent exports = %d, want 2", peak)
+	}
+}
+
+func TestMultiExporterAggregatesErrors(t *testing.T) {
+	var active, peak int32
+	errDB := errors.New("database is locked")
+	errWebhook := errors.New("webhook returned 500")
+	exporter := NewMultiExporter(
+		countingExporter{active: &active, peak: &peak, err: errDB},
+		countingExporter{active: &active, peak: &peak},
+		countingExporter{active: &active, peak: &peak, err: errWebhook},
+	)
+	exporter.ExportConcurrency = 3
+
+	err := exporter.Export(sampleArticles())
+	if !errors.Is(err, errDB) || !errors.Is(err, errWebhook) {
+		t.Errorf("Export() error = %v, want both sink errors", err)
+	}
+}
+
+// fakeClickHouse records the statements and rows sent by ClickHouseExporter.
+// Embedding the driver interfaces leaves unused methods unimplemented.
+type fakeClickHouse struct {
+	driver.Conn
+	execs   []string
+	batches []*fakeClickHouseBatch
+	closed  bool
+}
+
+type fakeClickHouseBatch struct {
+	driver.Batch
+	rows [][]any
+	sent bool
+}
+
+func (c *fakeClickHouse) Exec(ctx context.Context, query string, args ...any) error {
+	c.execs = append(c.execs, query)
+	return nil
+}
+
+func (c *fakeClickHouse) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	batch := &fakeClickHouseBatch{}
+	c.batches = append(c.batches, batch)
+	return batch, nil
+}
+
+func (c *fakeClickHouse) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (b *fakeClickHouseBatch) Append(v ...any) error {
+	b.rows = append(b.rows, v)
+	return nil
+}
+
+func (b *fakeClickHouseBatch) Send() error {
+	b.sent = true
+	return nil
+}
+
+func TestNewExporter(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out")
+	tests := []struct {
+		format string
+		opts   ExportOptions
+		want   Exporter
+		path   string
+	}{
+		{"csv", ExportOptions{}, &CSVExporter{}, output + ".csv"},
+		{"json", ExportOptions{}, &JSONExporter{}, output + ".json"},
+		{"ndjson", ExportOptions{}, &NDJSONExporter{}, output + ".ndjson"},
+		{"sqlite", ExportOptions{}, &SQLiteExporter{}, output + ".db"},
+		{"html", ExportOptions{}, &HTMLExporter{}, output + ".html"},
+		{"ics", ExportOptions{}, &ICSExporter{}, output + ".ics"},
+		{"sitemap", ExportOptions{}, &SitemapExporter{}, output + ".xml"},
+		{"clickhouse", ExportOptions{DSN: "clickhouse://localhost:9000/db"}, &ClickHouseExporter{}, ""},
+		{"queue", ExportOptions{QueueURL: "nats://localhost:4222", QueueTopic: "articles"}, &QueueExporter{}, output + ".seen.csv"},
+		{"elastic", ExportOptions{ElasticAddresses: []string{"http://localhost:9200"}, ElasticIndex: "articles"}, &ElasticExporter{}, ""},
+	}
+	for _, tt := range tests {
+		exporter, err := tt.opts.NewExporter(tt.format, output)
+		if err != nil {
+			t.Errorf("NewExporter(%q) error = %v", tt.format, err)
+			continue
+		}
+		if got, want := fmt.Sprintf("%T", exporter), fmt.Sprintf("%T", tt.want); got != want {
+			t.Errorf("NewExporter(%q) = %s, want %s", tt.format, got, want)
+		}
+		if got := ExportPath(tt.format, output); got != tt.path {
+			t.Errorf("ExportPath(%q) = %q, want %q", tt.format, got, tt.path)
+		}
+	}
+
+	// Formats that write a file produce it at ExportPath
+	exporter, err := NewExporter("csv", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(sampleArticles()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if _, err := os.Stat(output + ".csv"); err != nil {
+		t.Errorf("csv export not written to ExportPath: %v", err)
+	}
+}
+
+func TestNewExporterErrors(t *testing.T) {
+	for _, format := range []string{"bogus", "clickhouse", "queue", "elastic"} {
+		if _, err := NewExporter(format, "out"); err == nil {
+			t.Errorf("NewExporter(%q) succeeded without the settings it needs", format)
+		}
+	}
+	if _, err := (ExportOptions{QueueURL: "nats://localhost:4222"}).NewExporter("queue", "out"); err == nil {
+		t.Error("NewExporter(queue) succeeded without a topic")
+	}
+}
+
+func TestClickHouseExporter(t *testing.T) {
+	conn := &fakeClickHouse{}
+	exporter := NewClickHouseExporter("clickhouse://localhost:9000/analytics")
+	exporter.connect = func(string) (driver.Conn, error) { return conn, nil }
+
+	articles := sampleArticles()
+	if err := exporter.Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if len(conn.execs) != 1 || !strings.Contains(conn.execs[0], "ENGINE = MergeTree") || !strings.Contains(conn.execs[0], "ORDER BY date") {
+		t.Errorf("expected a MergeTree table ordered by date, got %v", conn.execs)
+	}
+	if len(conn.batches) != 1 || !conn.batches[0].sent {
+		t.Fatalf("expected one sent batch, got %+v", conn.batches)
+	}
+	rows := conn.batches[0].rows
+	if len(rows) != len(articles) {
+		t.Fatalf("batch has %d rows, want %d", len(rows), len(articles))
+	}
+	if rows[0][2] != articles[0].Title {
+		t.Errorf("title column = %v, want %q", rows[0][2], articles[0].Title)
+	}
+	if images, ok := rows[0][6].([]string); !ok || images == nil {
+		t.Errorf("images column = %#v, want a non-nil []string", rows[0][6])
+	}
+	if !conn.closed {
+		t.Error("connection was not closed")
+	}
+}
+
+func TestClickHouseExporterInvalidDSN(t *testing.T) {
+	if err := NewClickHouseExporter("://not a dsn").Export(sampleArticles()); err == nil {
+		t.Error("Expected error for an invalid DSN")
+	}
+}
+
+// fakeElastic serves the index and bulk endpoints of an Elasticsearch
+// cluster, recording what it receives
+type fakeElastic struct {
+	indexExists bool
+	created     string
+	bulk        []string
+	bulkErrors  string
+}
+
+func (f *fakeElastic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The client refuses to talk to anything that isn't Elasticsearch
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodHead && r.URL.Path == "/articles":
+		if !f.indexExists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/articles":
+		f.created = string(body)
+		f.indexExists = true
+		w.Write([]byte(`{"acknowledged":true}`))
+	case r.URL.Path == "/_bulk":
+		f.bulk = append(f.bulk, string(body))
+		if f.bulkErrors != "" {
+			w.Write([]byte(f.bulkErrors))
+			return
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	default:
+		http.Error(w, r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+func TestElasticExporter(t *testing.T) {
+	cluster := &fakeElastic{}
+	server := httptest.NewServer(cluster)
+	defer server.Close()
+
+	articles := sampleArticles()
+	articles[0].ID = "go122"
+	exporter := NewElasticExporter([]string{server.URL}, "articles")
+	if err := exporter.Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if !strings.Contains(cluster.created, `"mappings"`) || !strings.Contains(cluster.created, `"date": {"type": "date"}`) {
+		t.Errorf("index created with %q, want the article mapping", cluster.created)
+	}
+	if len(cluster.bulk) != 1 {
+		t.Fatalf("got %d bulk requests, want 1", len(cluster.bulk))
+	}
+
+	lines := strings.Split(strings.TrimSuffix(cluster.bulk[0], "\n"), "\n")
+	if len(lines) != 2*len(articles) {
+		t.Fatalf("bulk body has %d lines, want an action and a document per article:\n%s", len(lines), cluster.bulk[0])
+	}
+	wantIDs := []string{"go122", GenerateArticleID(articles[1])}
+	for i, article := range articles {
+		var action struct {
+			Index struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id"`
+			} `json:"index"`
+		}
+		if err := json.Unmarshal([]byte(lines[2*i]), &action); err != nil {
+			t.Fatalf("bad action line %q: %v", lines[2*i], err)
+		}
+		if action.Index.Index != "articles" || action.Index.ID != wantIDs[i] {
+			t.Errorf("action %d = %+v, want index articles with _id %q", i, action.Index, wantIDs[i])
+		}
+		var doc Article
+		if err := json.Unmarshal([]byte(lines[2*i+1]), &doc); err != nil {
+			t.Fatalf("bad document line %q: %v", lines[2*i+1], err)
+		}
+		if doc.Title != article.Title || doc.URL != article.URL || !doc.Date.Equal(article.Date) {
+			t.Errorf("document %d = %+v, want %+v", i, doc, article)
+		}
+	}
+
+	// A second export reuses the index and upserts the same documents
+	cluster.created = ""
+	if err := exporter.Export(articles); err != nil {
+		t.Fatalf("second Export failed: %v", err)
+	}
+	if cluster.created != "" {
+		t.Error("existing index was created again")
+	}
+	if len(cluster.bulk) != 2 || cluster.bulk[1] != cluster.bulk[0] {
+		t.Error("second export should send the same bulk payload")
+	}
+}
+
+func TestElasticExporterItemErrors(t *testing.T) {
+	cluster := &fakeElastic{
+		indexExists: true,
+		bulkErrors:  `{"errors":true,"items":[{"index":{"_id":"a","status":200}},{"index":{"_id":"b","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [date]"}}}]}`,
+	}
+	server := httptest.NewServer(cluster)
+	defer server.Close()
+
+	err := NewElasticExporter([]string{server.URL}, "articles").Export(sampleArticles())
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") || !strings.Contains(err.Error(), " b:") {
+		t.Errorf("Export() error = %v, want the failed item's error", err)
+	}
+}
+
+func TestXLSXExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "articles.xlsx")
+	if err := NewXLSXExporter(path).Export(sampleArticles()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("failed to reopen workbook: %v", err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Articles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want a header and 2 articles", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		t.Errorf("header = %v, want %v", rows[0], csvHeader)
+	}
+	if rows[1][2] != "Go 1.22 Released" || rows[1][3] != "https://go.dev/blog/go1.22" || rows[1][4] != "Range over integers & more" {
+		t.Errorf("first row = %v", rows[1])
+	}
+	if rows[1][5] != "2024-02-06 12:00:00" {
+		t.Errorf("formatted date = %q, want 2024-02-06 12:00:00", rows[1][5])
+	}
+
+	// The date is stored as an Excel serial date, not text
+	raw, err := f.GetCellValue("Articles", "F2", excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw != "45328.5" {
+		t.Errorf("raw date cell = %q, want the serial date 45328.5", raw)
+	}
+}
+
+func TestRSSExporter(t *testing.T) {
+	articles := sampleArticles()
+	for i := range articles {
+		articles[i].ID = GenerateArticleID(articles[i])
+		articles[i].SourceMagazineURL = "https://flipboard.com/@user/tech"
+	}
+	path := filepath.Join(t.TempDir(), "feed.rss")
+	if err := NewRSSExporter(path, "Tech").Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed struct {
+		XMLName xml.Name
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			Link  string `xml:"link"`
+			Items []struct {
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				Description string `xml:"description"`
+				GUID        string `xml:"guid"`
+				PubDate     string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("feed is not well-formed XML: %v", err)
+	}
+	if feed.XMLName.Local != "rss" || feed.Version != "2.0" {
+		t.Errorf("root = %s version %q, want rss 2.0", feed.XMLName.Local, feed.Version)
+	}
+	if feed.Channel.Title != "Tech" || feed.Channel.Link != "https://flipboard.com/@user/tech" {
+		t.Errorf("channel = %q, %q", feed.Channel.Title, feed.Channel.Link)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("feed has %d items, want 2", len(feed.Channel.Items))
+	}
+
+	item := feed.Channel.Items[1]
+	if item.Title != "<script>alert('xss')</script>" || item.Link != "https://example.com/post?a=1&b=2" || item.Description != "Tricky <b>markup</b>" {
+		t.Errorf("item didn't round-trip: %+v", item)
+	}
+	if item.GUID != articles[1].ID {
+		t.Errorf("guid = %q, want the article ID", item.GUID)
+	}
+	if date, err := time.Parse(time.RFC1123Z, item.PubDate); err != nil || !date.Equal(articles[1].Date) {
+		t.Errorf("pubDate = %q, want %v in RFC 1123 format", item.PubDate, articles[1].Date)
+	}
+}
+
+func TestMarkdownExporter(t *testing.T) {
+	articles := append(sampleArticles(),
+		Article{Title: "No link", Summary: "1 * 2 = 2_x"},
+		Article{Title: "Parens", URL: "https://en.wikipedia.org/wiki/Go_(programming_language)"},
+	)
+	path := filepath.Join(t.TempDir(), "articles.md")
+	if err := NewMarkdownExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `## [Go 1.22 Released](https://go.dev/blog/go1.22)
+
+Range over integers & more
+
+*February 6, 2024*
+
+## [\<script\>alert('xss')\</script\>](https://example.com/post?a=1&b=2)
+
+Tricky \<b\>markup\</b\>
+
+*March 1, 2024*
+
+## No link
+
+1 \* 2 = 2\_x
+
+## [Parens](https://en.wikipedia.org/wiki/Go_%28programming_language%29)
+`
+	if string(data) != want {
+		t.Errorf("Markdown export =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestSitemapExporter(t *testing.T) {
+	articles := append(sampleArticles(),
+		Article{Title: "Duplicate", URL: "https://go.dev/blog/go1.22"},
+		Article{Title: "No link"},
+	)
+	path := filepath.Join(t.TempDir(), "sitemap.xml")
+	if err := NewSitemapExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `<?xml version="1.0" encoding="UTF-8"?>`) {
+		t.Errorf("sitemap is missing the XML declaration:\n%s", data)
+	}
+
+	// Check the constraints of the sitemap 0.9 schema
+	var sitemap struct {
+		XMLName xml.Name
+		URLs    []struct {
+			Loc     string `xml:"loc"`
+			LastMod string `xml:"lastmod"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(data, &sitemap); err != nil {
+		t.Fatalf("sitemap is not well-formed XML: %v", err)
+	}
+	if sitemap.XMLName.Space != sitemapNamespace || sitemap.XMLName.Local != "urlset" {
+		t.Errorf("root element = %+v, want urlset in %s", sitemap.XMLName, sitemapNamespace)
+	}
+	if len(sitemap.URLs) != 2 {
+		t.Fatalf("sitemap has %d URLs, want 2 (deduped, empty skipped)", len(sitemap.URLs))
+	}
+	for _, u := range sitemap.URLs {
+		if len(u.Loc) == 0 || len(u.Loc) > 2048 || checkAbsoluteURL(u.Loc) != "" {
+			t.Errorf("loc %q is not a valid absolute URL under 2048 characters", u.Loc)
+		}
+		if u.LastMod == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, u.LastMod); err != nil {
+			t.Errorf("lastmod %q is not a W3C datetime: %v", u.LastMod, err)
+		}
+	}
+	if sitemap.URLs[0].LastMod != "2024-02-06T12:00:00Z" {
+		t.Errorf("lastmod = %q, want the article date", sitemap.URLs[0].LastMod)
+	}
+}
+
+// mockPublisher records the messages sent by QueueExporter, refusing those
+// at the indexes in reject
+type mockPublisher struct {
+	topic    string
+	messages [][]byte
+	err      error
+	reject   []int
+	closed   bool
+}
+
+func (p *mockPublisher) Publish(topic string, messages [][]byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.topic = topic
+	rejected := &rejectedMessages{}
+	for i, message := range messages {
+		if slices.Contains(p.reject, i) {
+			rejected.add(i, errors.New("maximum payload exceeded"))
+			continue
+		}
+		p.messages = append(p.messages, message)
+	}
+	if len(rejected.indexes) > 0 {
+		return rejected
+	}
+	return nil
+}
+
+func (p *mockPublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestQueueExporterPublishesOnlyNovelArticles(t *testing.T) {
+	publisher := &mockPublisher{}
+	seenFile := filepath.Join(t.TempDir(), "seen.csv")
+	newExporter := func() *QueueExporter {
+		exporter := NewQueueExporter("nats://localhost:4222", "articles.new", WithSeenFile(seenFile))
+		exporter.connect = func(string) (QueuePublisher, error) { return publisher, nil }
+		return exporter
+	}
+
+	articles := sampleArticles()
+	if err := newExporter().Export(articles[:1]); err != nil {
+		t.Fatalf("first Export failed: %v", err)
+	}
+	if len(publisher.messages) != 1 || publisher.topic != "articles.new" || !publisher.closed {
+		t.Fatalf("first run published %d messages to %q", len(publisher.messages), publisher.topic)
+	}
+
+	// The second run sees the first article again, twice, plus a new one
+	publisher.messages = nil
+	if err := newExporter().Export(append(articles, articles[1])); err != nil {
+		t.Fatalf("second Export failed: %v", err)
+	}
+	if len(publisher.messages) != 1 {
+		t.Fatalf("second run published %d messages, want only the new article", len(publisher.messages))
+	}
+	var published Article
+	if err := json.Unmarshal(publisher.messages[0], &published); err != nil {
+		t.Fatalf("message is not an article: %v", err)
+	}
+	if published.URL != articles[1].URL {
+		t.Errorf("published %s, want %s", published.URL, articles[1].URL)
+	}
+
+	// Nothing new is left for a third run
+	publisher.messages = nil
+	if err := newExporter().Export(articles); err != nil {
+		t.Fatalf("third Export failed: %v", err)
+	}
+	if len(publisher.messages) != 0 {
+		t.Errorf("third run published %d messages, want none", len(publisher.messages))
+	}
+}
+
+func TestQueueExporterFailedPublishIsRetried(t *testing.T) {
+	publisher := &mockPublisher{err: errors.New("broker down")}
+	exporter := NewQueueExporter("nats://localhost:4222", "articles", WithSeenFile(filepath.Join(t.TempDir(), "seen.csv")), WithQueueEncoding(QueueURL))
+	exporter.connect = func(string) (QueuePublisher, error) { return publisher, nil }
+
+	if err := exporter.Export(sampleArticles()); err == nil {
+		t.Fatal("expected an error when publishing fails")
+	}
+
+	publisher.err = nil
+	if err := exporter.Export(sampleArticles()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(publisher.messages) != 2 || string(publisher.messages[0]) != sampleArticles()[0].URL {
+		t.Errorf("messages = %q, want both article URLs", publisher.messages)
+	}
+}
+
+func TestQueueExporterRecordsArticlesDeliveredAlongsideRejects(t *testing.T) {
+	publisher := &mockPublisher{reject: []int{0}}
+	exporter := NewQueueExporter("nats://localhost:4222", "articles", WithSeenFile(filepath.Join(t.TempDir(), "seen.csv")), WithQueueEncoding(QueueURL))
+	exporter.connect = func(string) (QueuePublisher, error) { return publisher, nil }
+
+	articles := sampleArticles()
+	if err := exporter.Export(articles); err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("Export() error = %v, want the rejected article reported", err)
+	}
+	if len(publisher.messages) != 1 || string(publisher.messages[0]) != articles[1].URL {
+		t.Fatalf("messages = %q, want only the second article", publisher.messages)
+	}
+
+	// Only the rejected article is retried
+	publisher.messages, publisher.reject = nil, nil
+	if err := exporter.Export(articles); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(publisher.messages) != 1 || string(publisher.messages[0]) != articles[0].URL {
+		t.Errorf("retry published %q, want only the rejected article", publisher.messages)
+	}
+}
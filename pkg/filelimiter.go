@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimiter paces outgoing requests. *rate.Limiter satisfies it; custom
+// implementations can coordinate the rate across processes.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// lockRetryInterval is how long to wait between attempts to take the lock
+const lockRetryInterval = 2 * time.Millisecond
+
+// FileRateLimiter coordinates a shared request rate between independent
+// processes through a token file. The file holds the time of the next free
+// request slot; each Wait reserves a slot under an exclusive lock on the
+// file and sleeps until it arrives, so all processes using the same path
+// share one rate. The operating system releases the lock when its holder
+// exits, so a crashed process can't leave the file locked.
+type FileRateLimiter struct {
+	path     string
+	interval time.Duration
+}
+
+// NewFileRateLimiter creates a limiter allowing requestsPerSecond across all
+// processes that share path. requestsPerSecond must be positive.
+func NewFileRateLimiter(path string, requestsPerSecond float64) (*FileRateLimiter, error) {
+	if !(requestsPerSecond > 0) {
+		return nil, fmt.Errorf("invalid rate for %s: %v requests per second, must be positive", path, requestsPerSecond)
+	}
+	return &FileRateLimiter{
+		path:     path,
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}, nil
+}
+
+// Wait blocks until this process's reserved slot arrives or ctx is done
+func (l *FileRateLimiter) Wait(ctx context.Context) error {
+	slot, err := l.reserve(ctx)
+	if err != nil {
+		return err
+	}
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes the next free slot from the token file and advances it
+func (l *FileRateLimiter) reserve(ctx context.Context) (time.Time, error) {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open rate limit file: %w", err)
+	}
+	defer file.Close()
+	if err := lockFile(ctx, file); err != nil {
+		return time.Time{}, err
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read rate limit file: %w", err)
+	}
+	now := time.Now()
+	next := now
+	if nanos, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil {
+		if stored := time.Unix(0, nanos); stored.After(now) {
+			next = stored
+		}
+	}
+
+	following := []byte(strconv.FormatInt(next.Add(l.interval).UnixNano(), 10))
+	if err := file.Truncate(0); err != nil {
+		return time.Time{}, fmt.Errorf("failed to write rate limit file: %w", err)
+	}
+	if _, err := file.WriteAt(following, 0); err != nil {
+		return time.Time{}, fmt.Errorf("failed to write rate limit file: %w", err)
+	}
+	return next, nil
+}
+
+// lockFile takes an exclusive lock on file, retrying until it is free or
+// ctx is done
+func lockFile(ctx context.Context, file *os.File) error {
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to lock rate limit file: %w", err)
+		}
+		if locked {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
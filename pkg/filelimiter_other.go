@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package pkg
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile reports that file locking isn't available on this platform
+func tryLockFile(file *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// unlockFile is a no-op, since tryLockFile never locks
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func mustNewFileRateLimiter(t *testing.T, path string, requestsPerSecond float64) *FileRateLimiter {
+	t.Helper()
+	limiter, err := NewFileRateLimiter(path, requestsPerSecond)
+	if err != nil {
+		t.Fatalf("NewFileRateLimiter() error = %v", err)
+	}
+	return limiter
+}
+
+func TestFileRateLimiterSharedAcrossClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flipboard.rate")
+	const rps = 20.0
+	clients := []*FileRateLimiter{
+		mustNewFileRateLimiter(t, path, rps),
+		mustNewFileRateLimiter(t, path, rps),
+	}
+
+	const perClient = 5
+	var (
+		mu    sync.Mutex
+		times []time.Time
+		wg    sync.WaitGroup
+	)
+	start := time.Now()
+	for _, client := range clients {
+		for i := 0; i < perClient; i++ {
+			wg.Add(1)
+			go func(l *FileRateLimiter) {
+				defer wg.Done()
+				if err := l.Wait(context.Background()); err != nil {
+					t.Errorf("Wait() error = %v", err)
+					return
+				}
+				mu.Lock()
+				times = append(times, time.Now())
+				mu.Unlock()
+			}(client)
+		}
+	}
+	wg.Wait()
+
+	// Ten requests at a combined 20/s need at least nine intervals
+	total := len(clients) * perClient
+	minimum := time.Duration(float64(total-1)/rps*float64(time.Second)) - 20*time.Millisecond
+	if elapsed := time.Since(start); elapsed < minimum {
+		t.Errorf("%d requests took %v, want at least %v for a shared %v/s rate", total, elapsed, minimum, rps)
+	}
+	if len(times) != total {
+		t.Errorf("got %d completed waits, want %d", len(times), total)
+	}
+}
+
+func TestFileRateLimiterCancelled(t *testing.T) {
+	limiter := mustNewFileRateLimiter(t, filepath.Join(t.TempDir(), "slow.rate"), 0.1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Expected error when context expires before the next slot")
+	}
+}
+
+func TestNewFileRateLimiterRejectsNonPositiveRate(t *testing.T) {
+	for _, rps := range []float64{0, -1} {
+		if _, err := NewFileRateLimiter(filepath.Join(t.TempDir(), "flipboard.rate"), rps); err == nil {
+			t.Errorf("NewFileRateLimiter(%v) error = nil, want an error", rps)
+		}
+	}
+}
+
+// lockHolderEnv names the token file TestFileRateLimiterLockHolder locks
+// when the test binary runs as a helper process
+const lockHolderEnv = "FLIPBOARD_SCRAPER_LOCK_HOLDER"
+
+// TestFileRateLimiterLockHolder is not a real test: run as a helper
+// process, it locks a token file and holds it until killed
+func TestFileRateLimiterLockHolder(t *testing.T) {
+	path := os.Getenv(lockHolderEnv)
+	if path == "" {
+		return
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lockFile(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("locked")
+	time.Sleep(time.Hour)
+}
+
+func TestFileRateLimiterLockFromKilledProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flipboard.rate")
+	holder := exec.Command(os.Args[0], "-test.run=^TestFileRateLimiterLockHolder$")
+	holder.Env = append(os.Environ(), lockHolderEnv+"="+path)
+	stdout, err := holder.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := holder.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "locked\n" {
+		holder.Process.Kill()
+		t.Fatalf("helper process didn't lock the file: %q, %v", line, err)
+	}
+
+	// No slot is handed out while another process holds the lock
+	const rps = 1000
+	limiter := mustNewFileRateLimiter(t, path, rps)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.reserve(ctx); err == nil {
+		t.Error("reserve() succeeded while another process held the lock")
+	}
+
+	// Once the holder dies, contending clients each get their own slot
+	holder.Process.Kill()
+	holder.Wait()
+	const clients = 20
+	slots := make([]time.Time, clients)
+	var wg sync.WaitGroup
+	for i := range slots {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			slot, err := mustNewFileRateLimiter(t, path, rps).reserve(ctx)
+			if err != nil {
+				t.Errorf("reserve() after the holder died error = %v", err)
+			}
+			slots[i] = slot
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Before(slots[j]) })
+	for i := 1; i < len(slots); i++ {
+		if gap := slots[i].Sub(slots[i-1]); gap < time.Second/rps {
+			t.Errorf("slots %d and %d are %v apart, want at least %v", i-1, i, gap, time.Second/rps)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"strings"
+	"time"
+)
+
+// FilterOptions selects the articles FilterArticles keeps. Zero fields
+// don't filter.
+type FilterOptions struct {
+	// Keyword must appear in the title or summary, ignoring case
+	Keyword string
+	// Since and Until bound Article.Date, inclusively. Articles without a
+	// date are dropped when either is set.
+	Since time.Time
+	Until time.Time
+}
+
+// FilterArticles returns the articles matching opts, in their original order
+func FilterArticles(articles []Article, opts FilterOptions) []Article {
+	keyword := strings.ToLower(opts.Keyword)
+	matched := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		if keyword != "" &&
+			!strings.Contains(strings.ToLower(article.Title), keyword) &&
+			!strings.Contains(strings.ToLower(article.Summary), keyword) {
+			continue
+		}
+		if (!opts.Since.IsZero() || !opts.Until.IsZero()) && article.Date.IsZero() {
+			continue
+		}
+		if !opts.Since.IsZero() && article.Date.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && article.Date.After(opts.Until) {
+			continue
+		}
+		matched = append(matched, article)
+	}
+	return matched
+}
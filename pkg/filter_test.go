@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterArticlesZeroDates(t *testing.T) {
+	articles := []Article{{Title: "Undated"}, {Title: "Dated", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	if got := FilterArticles(articles, FilterOptions{Keyword: "dated"}); len(got) != 2 {
+		t.Errorf("got %d articles without a date range, want both", len(got))
+	}
+	got := FilterArticles(articles, FilterOptions{Until: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)})
+	if len(got) != 1 || got[0].Title != "Dated" {
+		t.Errorf("got %v with -until only, want the undated article dropped", got)
+	}
+}
+
+func TestFilterArticlesScrapedUndated(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Dated</h3><a href="https://example.com/1">Read</a><time datetime="2024-03-01T12:00:00Z">Mar 1</time></article>
+<article class="item"><h3>Undated</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mixed")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+
+	// Neither bound lets the undated article through as if it were dated now
+	for _, opts := range []FilterOptions{
+		{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Until: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+	} {
+		got := FilterArticles(articles, opts)
+		if len(got) != 1 || got[0].Title != "Dated" {
+			t.Errorf("FilterArticles(%+v) = %v, want only the dated article", opts, got)
+		}
+	}
+}
+
+func TestFilterArticles(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	articles := []Article{
+		{Title: "Go 1.22 Released", Summary: "Range over integers", Date: day(1)},
+		{Title: "Rust in the kernel", Summary: "Why the GO community cares", Date: day(10)},
+		{Title: "Weekly roundup", Summary: "Links", Date: day(20)},
+		{Title: "Undated go notes"},
+	}
+
+	tests := []struct {
+		name string
+		opts FilterOptions
+		want string
+	}{
+		{"no filter", FilterOptions{}, "Go 1.22 Released|Rust in the kernel|Weekly roundup|Undated go notes"},
+		{"keyword in title or summary", FilterOptions{Keyword: "go"}, "Go 1.22 Released|Rust in the kernel|Undated go notes"},
+		{"keyword case-insensitive", FilterOptions{Keyword: "INTEGERS"}, "Go 1.22 Released"},
+		{"keyword without match", FilterOptions{Keyword: "python"}, ""},
+		{"since", FilterOptions{Since: day(10)}, "Rust in the kernel|Weekly roundup"},
+		{"until", FilterOptions{Until: day(10)}, "Go 1.22 Released|Rust in the kernel"},
+		{"range", FilterOptions{Since: day(2), Until: day(19)}, "Rust in the kernel"},
+		{"inclusive bounds", FilterOptions{Since: day(1), Until: day(20)}, "Go 1.22 Released|Rust in the kernel|Weekly roundup"},
+		{"bounds a nanosecond off", FilterOptions{Since: day(1).Add(time.Nanosecond), Until: day(20).Add(-time.Nanosecond)}, "Rust in the kernel"},
+		{"keyword and range", FilterOptions{Keyword: "go", Since: day(5)}, "Rust in the kernel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titles []string
+			for _, article := range FilterArticles(articles, tt.opts) {
+				titles = append(titles, article.Title)
+			}
+			if got := strings.Join(titles, "|"); got != tt.want {
+				t.Errorf("FilterArticles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
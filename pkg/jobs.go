@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job describes one scrape in a batch: which magazines to scrape, how to
+// export them, and any configuration that differs from the base config
+type Job struct {
+	Name   string       `json:"name"`
+	URLs   []string     `json:"urls"`
+	Format string       `json:"format"`
+	Output string       `json:"output"`
+	Config JobOverrides `json:"config"`
+}
+
+// JobOverrides holds per-job configuration. Fields left out of the job
+// spec are nil and keep the value from the base config.
+type JobOverrides struct {
+	ConcurrentRequests *int     `json:"concurrent,omitempty"`
+	RequestsPerSecond  *float64 `json:"rate_limit,omitempty"`
+	TimeoutSeconds     *int     `json:"timeout,omitempty"`
+	RequestTimeout     *int     `json:"request_timeout,omitempty"`
+	SlowStart          *bool    `json:"slow_start,omitempty"`
+	ExtractComments    *bool    `json:"comments,omitempty"`
+	AdaptivePacing     *bool    `json:"adaptive_pacing,omitempty"`
+	MaxRedirects       *int     `json:"max_redirects,omitempty"`
+	MaxArticlesTotal   *int     `json:"max_articles,omitempty"`
+}
+
+// Apply returns base with the overrides applied
+func (o JobOverrides) Apply(base ScraperConfig) ScraperConfig {
+	config := base
+	if o.ConcurrentRequests != nil {
+		config.ConcurrentRequests = *o.ConcurrentRequests
+	}
+	if o.RequestsPerSecond != nil {
+		config.RequestsPerSecond = *o.RequestsPerSecond
+	}
+	if o.TimeoutSeconds != nil {
+		config.Timeout = time.Duration(*o.TimeoutSeconds) * time.Second
+	}
+	if o.RequestTimeout != nil {
+		config.RequestTimeout = time.Duration(*o.RequestTimeout) * time.Second
+	}
+	if o.SlowStart != nil {
+		config.SlowStart = *o.SlowStart
+	}
+	if o.ExtractComments != nil {
+		config.ExtractComments = *o.ExtractComments
+	}
+	if o.AdaptivePacing != nil {
+		config.AdaptivePacing = *o.AdaptivePacing
+	}
+	if o.MaxRedirects != nil {
+		config.MaxRedirects = *o.MaxRedirects
+	}
+	if o.MaxArticlesTotal != nil {
+		config.MaxArticlesTotal = *o.MaxArticlesTotal
+	}
+	return config
+}
+
+// LoadJobs reads a JSON array of job specs from path
+func LoadJobs(path string) ([]Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs file: %w", err)
+	}
+
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs file: %w", err)
+	}
+
+	for i, job := range jobs {
+		if len(job.URLs) == 0 {
+			return nil, fmt.Errorf("job %d (%s) has no URLs", i, job.Name)
+		}
+		if job.Format == "" || job.Output == "" {
+			return nil, fmt.Errorf("job %d (%s) needs both a format and an output", i, job.Name)
+		}
+	}
+	return jobs, nil
+}
+
+// ExportFunc writes articles to output in the given format
+type ExportFunc func(format, output string, articles []Article) error
+
+// JobResult reports the outcome of a single job
+type JobResult struct {
+	Job      Job
+	Articles int
+	Err      error
+}
+
+// RunJobs runs each job with its own scraper, at most parallel at a time,
+// exporting results with export. Results are returned in job order.
+func RunJobs(ctx context.Context, jobs []Job, base ScraperConfig, parallel int, export ExportFunc) []JobResult {
+	return runJobs(ctx, jobs, base, parallel, export, NewMagazineScraper)
+}
+
+// runJobs is RunJobs with a configurable scraper constructor
+func runJobs(ctx context.Context, jobs []Job, base ScraperConfig, parallel int, export ExportFunc,
+	newScraper func(ScraperConfig) (*MagazineScraper, error)) []JobResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]JobResult, len(jobs))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, job Job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = JobResult{Job: job}
+			scraper, err := newScraper(job.Config.Apply(base))
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer scraper.Close()
+			articles, err := scraper.ScrapeURLs(ctx, job.URLs)
+			results[i].Articles = len(articles)
+			if len(articles) == 0 {
+				if err == nil {
+					err = fmt.Errorf("no articles were scraped")
+				}
+				results[i].Err = err
+				return
+			}
+
+			if err := export(job.Format, job.Output, articles); err != nil {
+				results[i].Err = fmt.Errorf("export failed: %w", err)
+			}
+		}(i, job)
+	}
+	wg.Wait()
+
+	return results
+}
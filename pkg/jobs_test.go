@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunJobs(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a><span class="comment">Nice</span></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	server := httptest.NewServer(fixtureHandler(page))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	dir := t.TempDir()
+	spec := fmt.Sprintf(`[
+		{"name": "full", "urls": ["https://flipboard.com/@user/a"], "format": "csv", "output": %q},
+		{"name": "preview", "urls": ["https://flipboard.com/@user/b"], "format": "csv", "output": %q,
+		 "config": {"comments": true, "max_articles": 1}}
+	]`, filepath.Join(dir, "full"), filepath.Join(dir, "preview"))
+	specPath := filepath.Join(dir, "jobs.json")
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := LoadJobs(specPath)
+	if err != nil {
+		t.Fatalf("LoadJobs() error = %v", err)
+	}
+
+	export := func(format, output string, articles []Article) error {
+		return NewCSVExporter(output + "." + format).Export(articles)
+	}
+	newScraper := func(config ScraperConfig) (*MagazineScraper, error) {
+		scraper, err := NewMagazineScraper(config)
+		if err != nil {
+			return nil, err
+		}
+		scraper.collector.WithTransport(&rewriteTransport{target: target})
+		return scraper, nil
+	}
+
+	results := runJobs(context.Background(), jobs, DefaultConfig(), 2, export, newScraper)
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("job %s failed: %v", result.Job.Name, result.Err)
+		}
+	}
+
+	full := readCSVColumn(t, filepath.Join(dir, "full.csv"), "Top Comments")
+	if len(full) != 2 {
+		t.Errorf("full job exported %d rows, want 2", len(full))
+	}
+	if full[0] != "" {
+		t.Errorf("full job should not extract comments, got %q", full[0])
+	}
+
+	preview := readCSVColumn(t, filepath.Join(dir, "preview.csv"), "Top Comments")
+	if len(preview) != 1 {
+		t.Fatalf("preview job exported %d rows, want 1", len(preview))
+	}
+	if preview[0] != "Nice" {
+		t.Errorf("preview job comments = %q, want %q", preview[0], "Nice")
+	}
+}
+
+func TestRunJobsClosesScrapers(t *testing.T) {
+	server := httptest.NewServer(fixtureHandler(`<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	jobs := []Job{
+		{Name: "a", URLs: []string{"https://flipboard.com/@user/a"}},
+		{Name: "b", URLs: []string{"https://flipboard.com/@user/b"}},
+	}
+	var tickers []*tickerLimiter
+	newScraper := func(config ScraperConfig) (*MagazineScraper, error) {
+		scraper, err := NewMagazineScraper(config)
+		if err != nil {
+			return nil, err
+		}
+		scraper.collector.WithTransport(&rewriteTransport{target: target})
+		tickers = append(tickers, scraper.limiter.(*tickerLimiter))
+		return scraper, nil
+	}
+
+	config := DefaultConfig()
+	config.SmoothPacing = true
+	config.RequestsPerSecond = 100
+	export := func(string, string, []Article) error { return nil }
+	for _, result := range runJobs(context.Background(), jobs, config, 1, export, newScraper) {
+		if result.Err != nil {
+			t.Fatalf("job %s failed: %v", result.Job.Name, result.Err)
+		}
+	}
+
+	for i, ticker := range tickers {
+		select {
+		case <-ticker.done:
+		default:
+			t.Errorf("job %d left its smooth pacing ticker running", i)
+		}
+	}
+}
+
+func TestLoadJobsValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	if err := os.WriteFile(path, []byte(`[{"name": "empty", "format": "csv", "output": "out"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadJobs(path); err == nil {
+		t.Error("Expected error for job without URLs")
+	}
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import "log"
+
+// Logger receives the scraper's progress events: each request, its
+// completion, retries and failures. Implementations must be safe for
+// concurrent use, since magazines are scraped in parallel.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// nopLogger discards every event; it is used when ScraperConfig.Logger is nil
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Warnf(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// StdLogger adapts a standard library *log.Logger to Logger, prefixing
+// each line with its level
+type StdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger returns a Logger writing through logger, or through the
+// standard logger when nil
+func NewStdLogger(logger *log.Logger) *StdLogger {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &StdLogger{logger: logger}
+}
+
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Printf("DEBUG "+format, args...)
+}
+
+func (l *StdLogger) Infof(format string, args ...interface{}) {
+	l.logger.Printf("INFO "+format, args...)
+}
+
+func (l *StdLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Printf("WARN "+format, args...)
+}
+
+func (l *StdLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Printf("ERROR "+format, args...)
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps every event as "LEVEL message"
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *recordingLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.record("DEBUG", format, args...)
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.record("INFO", format, args...)
+}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.record("WARN", format, args...)
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.record("ERROR", format, args...)
+}
+
+func TestLoggerEvents(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Back</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	handler, _ := flakyHandler(http.StatusServiceUnavailable, 1, page)
+	logger := &recordingLogger{}
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 1
+	config.RetryBackoff = time.Millisecond
+	config.Logger = logger
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/flaky"}); err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+
+	want := []string{
+		"INFO scraping 1 magazines",
+		"DEBUG requesting https://flipboard.com/@user/flaky",
+		"WARN retrying https://flipboard.com/@user/flaky (attempt 1 of 1)",
+		"DEBUG requesting https://flipboard.com/@user/flaky",
+		"INFO scraped https://flipboard.com/@user/flaky: 1 articles",
+		"INFO scraped 1 articles from 1 magazines, 0 failed",
+	}
+	if len(logger.events) != len(want) {
+		t.Fatalf("got events %q, want %d", logger.events, len(want))
+	}
+	for i, event := range logger.events {
+		if !strings.HasPrefix(event, want[i]) {
+			t.Errorf("event %d = %q, want prefix %q", i, event, want[i])
+		}
+	}
+}
+
+func TestLoggerScrapeError(t *testing.T) {
+	handler, _ := flakyHandler(http.StatusNotFound, 10, "")
+	logger := &recordingLogger{}
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.Logger = logger
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/missing"); err == nil {
+		t.Fatal("ScrapeURL() error = nil, want the 404")
+	}
+	last := logger.events[len(logger.events)-1]
+	if !strings.HasPrefix(last, "ERROR failed to scrape https://flipboard.com/@user/missing") {
+		t.Errorf("last event = %q, want the failure", last)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0))
+	logger.Infof("scraped %d articles", 3)
+	logger.Errorf("failed: %v", "boom")
+	if got, want := buf.String(), "INFO scraped 3 articles\nERROR failed: boom\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
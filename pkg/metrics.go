@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics counts the requests and articles of a ScrapeURLsWithMetrics run
+type Metrics struct {
+	// Requests counts every page request, including retries, later pages
+	// and refetches after accepting a consent wall
+	Requests int
+	// Successes and Failures split Requests by whether a page was returned
+	Successes int
+	Failures  int
+	// Retries counts the requests repeated after a transient failure
+	Retries int
+	// Articles is how many articles the run returned
+	Articles int
+	Elapsed  time.Duration
+}
+
+// metricsRecorder accumulates Metrics while magazines are scraped
+// concurrently. A nil recorder records nothing.
+type metricsRecorder struct {
+	requests  atomic.Int64
+	successes atomic.Int64
+	failures  atomic.Int64
+	retries   atomic.Int64
+}
+
+// request records the outcome of a page request; an unchanged magazine
+// was still fetched successfully
+func (m *metricsRecorder) request(err error) {
+	if m == nil {
+		return
+	}
+	m.requests.Add(1)
+	if err == nil || errors.Is(err, ErrMagazineUnchanged) {
+		m.successes.Add(1)
+	} else {
+		m.failures.Add(1)
+	}
+}
+
+// retry records that a failed request is about to be repeated
+func (m *metricsRecorder) retry() {
+	if m != nil {
+		m.retries.Add(1)
+	}
+}
+
+// metrics returns the totals recorded so far
+func (m *metricsRecorder) metrics() Metrics {
+	return Metrics{
+		Requests:  int(m.requests.Load()),
+		Successes: int(m.successes.Load()),
+		Failures:  int(m.failures.Load()),
+		Retries:   int(m.retries.Load()),
+	}
+}
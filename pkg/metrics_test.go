@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScrapeURLsWithMetrics(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	// The flaky magazine fails once before loading
+	var flaky atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/@user/flaky" && flaky.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 2
+	config.RetryBackoff = time.Millisecond
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, metrics, err := scraper.ScrapeURLsWithMetrics(context.Background(), []string{
+		"https://flipboard.com/@user/steady",
+		"https://flipboard.com/@user/flaky",
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLsWithMetrics() error = %v", err)
+	}
+	want := Metrics{Requests: 3, Successes: 2, Failures: 1, Retries: 1, Articles: 4}
+	if metrics.Elapsed <= 0 {
+		t.Errorf("Elapsed = %v, want the run's duration", metrics.Elapsed)
+	}
+	metrics.Elapsed = 0
+	if metrics != want {
+		t.Errorf("metrics = %+v, want %+v", metrics, want)
+	}
+	if len(articles) != metrics.Articles {
+		t.Errorf("got %d articles, metrics count %d", len(articles), metrics.Articles)
+	}
+}
@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteMigration brings the articles table from version-1 to version by
+// adding a single column
+type sqliteMigration struct {
+	version    int
+	column     string
+	definition string
+}
+
+// sqliteMigrations lists every schema change since the original table, in
+// order. Version 1 is the table as first released; add a step here whenever
+// a column is added to the CREATE TABLE statement in SQLiteExporter.
+var sqliteMigrations = []sqliteMigration{
+	{version: 2, column: "images", definition: "TEXT"},
+	{version: 3, column: "article_id", definition: "TEXT"},
+	{version: 4, column: "top_comments", definition: "TEXT"},
+	{version: 5, column: "flipboard_id", definition: "TEXT"},
+	{version: 6, column: "sentiment", definition: "TEXT"},
+	{version: 7, column: "related_urls", definition: "TEXT"},
+	{version: 8, column: "curator", definition: "TEXT"},
+	{version: 9, column: "publisher_logo_url", definition: "TEXT"},
+	{version: 10, column: "flipped_by", definition: "TEXT"},
+	{version: 11, column: "author", definition: "TEXT"},
+	{version: 12, column: "image_url", definition: "TEXT"},
+	{version: 13, column: "magazine", definition: "TEXT"},
+	{version: 14, column: "tags", definition: "TEXT"},
+	{version: 15, column: "source_magazine_url", definition: "TEXT"},
+}
+
+// currentSchemaVersion is the version of a freshly created articles table
+func currentSchemaVersion() int {
+	return sqliteMigrations[len(sqliteMigrations)-1].version
+}
+
+// migrateSQLite records the schema version of a database and upgrades an
+// existing articles table to the current schema. Each applied version is
+// kept as a row in schema_version, forming a changelog of the database.
+func migrateSQLite(db *sql.DB, created bool) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	// A new table already has every column
+	if created {
+		if version == 0 {
+			_, err := db.Exec("INSERT INTO schema_version (version) VALUES (?)", currentSchemaVersion())
+			if err != nil {
+				return fmt.Errorf("failed to record schema version: %w", err)
+			}
+		}
+		return nil
+	}
+
+	// Databases written before versioning existed are treated as version 1
+	if version == 0 {
+		version = 1
+	}
+
+	columns, err := sqliteColumns(db, "articles")
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	for _, m := range sqliteMigrations {
+		if m.version <= version {
+			continue
+		}
+		// Columns may already exist in databases from unversioned builds
+		if !columns[m.column] {
+			if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE articles ADD COLUMN %s %s", m.column, m.definition)); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to migrate to version %d: %w", m.version, err)
+			}
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", m.version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record schema version %d: %w", m.version, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteTableExists reports whether the named table exists
+func sqliteTableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	return count > 0, nil
+}
+
+// sqliteColumns returns the set of column names in table
+func sqliteColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return nil, fmt.Errorf("failed to read column info: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// ensureSQLiteURLIndex adds the unique index that keeps one row per article
+// URL. Tables written before the index existed may hold duplicates, which
+// are removed first, keeping the earliest row for each URL. Articles without
+// a URL are not indexed.
+func ensureSQLiteURLIndex(db *sql.DB) error {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_articles_url'").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration: %w", err)
+	}
+	_, err = tx.Exec(`
+		DELETE FROM articles
+		WHERE url <> '' AND rowid NOT IN (
+			SELECT MIN(rowid) FROM articles WHERE url <> '' GROUP BY url
+		)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to remove duplicate articles: %w", err)
+	}
+	if _, err := tx.Exec("CREATE UNIQUE INDEX idx_articles_url ON articles(url) WHERE url <> ''"); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create url index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
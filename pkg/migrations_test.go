@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteMigratesVersion1Database(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v1.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// The original schema, before any columns were added
+	_, err = db.Exec(`
+		CREATE TABLE articles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			url TEXT,
+			summary TEXT,
+			date DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("failed to create v1 table: %v", err)
+	}
+	// Older versions inserted every export, leaving duplicate URLs behind
+	for i := 0; i < 2; i++ {
+		if _, err := db.Exec("INSERT INTO articles (title, url) VALUES ('Legacy', 'https://example.com/legacy')"); err != nil {
+			t.Fatalf("failed to insert legacy row: %v", err)
+		}
+	}
+
+	if err := NewSQLiteExporter(path).Export(sampleArticles()); err != nil {
+		t.Fatalf("Export() on v1 database error = %v", err)
+	}
+
+	columns, err := sqliteColumns(db, "articles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range sqliteMigrations {
+		if !columns[m.column] {
+			t.Errorf("column %s missing after migration", m.column)
+		}
+	}
+
+	var version, steps int
+	if err := db.QueryRow("SELECT MAX(version), COUNT(*) FROM schema_version").Scan(&version, &steps); err != nil {
+		t.Fatalf("failed to read schema_version: %v", err)
+	}
+	if version != currentSchemaVersion() {
+		t.Errorf("schema version = %d, want %d", version, currentSchemaVersion())
+	}
+	if steps != len(sqliteMigrations) {
+		t.Errorf("recorded %d migration steps, want %d", steps, len(sqliteMigrations))
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM articles").Scan(&count); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if count != 1+len(sampleArticles()) {
+		t.Errorf("got %d rows, want %d", count, 1+len(sampleArticles()))
+	}
+
+	// Exporting again leaves the version alone
+	if err := NewSQLiteExporter(path).Export(sampleArticles()); err != nil {
+		t.Fatalf("second Export() error = %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&steps); err != nil {
+		t.Fatal(err)
+	}
+	if steps != len(sqliteMigrations) {
+		t.Errorf("recorded %d migration steps after re-export, want %d", steps, len(sqliteMigrations))
+	}
+}
+
+func TestSQLiteNewDatabaseRecordsCurrentVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.db")
+	if err := NewSQLiteExporter(path).Export(sampleArticles()); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var version int
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		t.Fatalf("failed to read schema_version: %v", err)
+	}
+	if version != currentSchemaVersion() {
+		t.Errorf("schema version = %d, want %d", version, currentSchemaVersion())
+	}
+}
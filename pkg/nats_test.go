@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeNATSServer accepts one client, greets it with info, records the
+// protocol lines it sends and answers each PING with reply
+func fakeNATSServer(t *testing.T, info, reply string) (string, <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO " + info + "\r\n"))
+
+		var lines []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimSpace(line)
+			lines = append(lines, line)
+			if line == "PING" {
+				conn.Write([]byte(reply + "\r\n"))
+			}
+		}
+		received <- lines
+	}()
+	return "nats://" + listener.Addr().String(), received
+}
+
+// published returns the PUB lines and payloads among lines
+func published(lines []string) []string {
+	var pubs []string
+	for i, line := range lines {
+		if strings.HasPrefix(line, "PUB ") && i+1 < len(lines) {
+			pubs = append(pubs, line, lines[i+1])
+		}
+	}
+	return pubs
+}
+
+func TestNATSPublisher(t *testing.T) {
+	brokerURL, received := fakeNATSServer(t, `{"server_id":"test","max_payload":1048576}`, "PONG")
+
+	publisher, err := dialNATS(brokerURL)
+	if err != nil {
+		t.Fatalf("dialNATS() error = %v", err)
+	}
+	if err := publisher.Publish("articles.new", [][]byte{[]byte("one"), []byte("second")}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	publisher.Close()
+
+	lines := <-received
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "CONNECT {") {
+		t.Fatalf("server received %q, want CONNECT first", lines)
+	}
+	want := []string{"PUB articles.new 3", "one", "PUB articles.new 6", "second"}
+	if got := published(lines); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("published %q, want %q", got, want)
+	}
+}
+
+func TestNATSPublisherServerError(t *testing.T) {
+	brokerURL, _ := fakeNATSServer(t, `{"server_id":"test"}`, "-ERR 'Authorization Violation'")
+
+	publisher, err := dialNATS(brokerURL)
+	if err == nil {
+		publisher.Close()
+		t.Fatal("dialNATS() succeeded with credentials the server rejected")
+	}
+	if !strings.Contains(strings.ToLower(err.Error()), "authorization violation") {
+		t.Errorf("dialNATS() error = %v, want the server's error", err)
+	}
+}
+
+func TestNATSPublisherSkipsOversizedMessages(t *testing.T) {
+	brokerURL, received := fakeNATSServer(t, `{"server_id":"test","max_payload":8}`, "PONG")
+
+	publisher, err := dialNATS(brokerURL)
+	if err != nil {
+		t.Fatalf("dialNATS() error = %v", err)
+	}
+	err = publisher.Publish("articles", [][]byte{[]byte("one"), []byte("far too long"), []byte("two")})
+	publisher.Close()
+
+	var rejected *rejectedMessages
+	if !errors.As(err, &rejected) || len(rejected.indexes) != 1 || rejected.indexes[0] != 1 {
+		t.Fatalf("Publish() error = %v, want only the oversized message rejected", err)
+	}
+	want := []string{"PUB articles 3", "one", "PUB articles 3", "two"}
+	if got := published(<-received); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("published %q, want %q", got, want)
+	}
+}
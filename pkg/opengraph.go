@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// openGraph holds the Open Graph tags read from an article's own page
+type openGraph struct {
+	title       string
+	description string
+	image       string
+}
+
+// needsOpenGraph reports whether article is missing a field that Open Graph
+// tags could fill
+func needsOpenGraph(article Article) bool {
+	return article.URL != "" && (article.Title == "" || article.Summary == "" || article.ImageURL == "")
+}
+
+// fetchOpenGraph reads the og:title, og:description and og:image tags of the
+// page at articleURL, waiting for the rate limiter like any other request
+func (s *MagazineScraper) fetchOpenGraph(ctx context.Context, articleURL string) (openGraph, error) {
+	if err := s.wait(ctx, articleURL); err != nil {
+		return openGraph{}, err
+	}
+
+	c := s.newCollector()
+	var og openGraph
+	var fetchErr error
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		og.title = cleanText(e.ChildAttr(`meta[property="og:title"]`, "content"))
+		og.description = cleanText(e.ChildAttr(`meta[property="og:description"]`, "content"))
+		if image := e.ChildAttr(`meta[property="og:image"]`, "content"); image != "" {
+			og.image = e.Request.AbsoluteURL(image)
+		}
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		fetchErr = fmt.Errorf("article page returned status %d: %w", r.StatusCode, err)
+	})
+
+	if err := c.Visit(articleURL); err != nil && fetchErr == nil {
+		return openGraph{}, err
+	}
+	return og, fetchErr
+}
+
+// fill sets the article fields still empty from the Open Graph tags
+func (og openGraph) fill(article *Article) {
+	if article.Title == "" {
+		article.Title = og.title
+	}
+	if article.Summary == "" {
+		article.Summary = og.description
+	}
+	if article.ImageURL == "" && og.image != "" {
+		article.ImageURL = og.image
+		if !slices.Contains(article.Images, og.image) {
+			article.Images = append(article.Images, og.image)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchOpenGraphFillsEmptyFields(t *testing.T) {
+	magazine := `<html><body>
+<article class="item"><a href="https://news.example.com/bare">Read</a></article>
+<article class="item"><h3>Own title</h3><p class="description">Own summary</p><img src="https://cdn.example.com/own.jpg"><a href="https://news.example.com/complete">Read</a></article>
+<article class="item"><h3>Broken</h3><a href="https://news.example.com/missing">Read</a></article>
+</body></html>`
+	destination := `<html><head>
+<meta property="og:title" content="OG title">
+<meta property="og:description" content="OG description">
+<meta property="og:image" content="/og.jpg">
+</head><body></body></html>`
+
+	var completeFetched atomic.Bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/@user/mag":
+			w.Write([]byte(magazine))
+		case "/bare":
+			w.Write([]byte(destination))
+		case "/complete":
+			completeFetched.Store(true)
+			w.Write([]byte(destination))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.FetchOpenGraph = true
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Fatalf("got %d articles, want 3: %+v", len(articles), articles)
+	}
+
+	bare := articles[0]
+	if bare.Title != "OG title" || bare.Summary != "OG description" || bare.ImageURL != "https://news.example.com/og.jpg" {
+		t.Errorf("bare item = %q, %q, %q, want it filled from the OG tags", bare.Title, bare.Summary, bare.ImageURL)
+	}
+	if len(bare.Images) != 1 || bare.Images[0] != bare.ImageURL {
+		t.Errorf("bare item Images = %v, want the OG image", bare.Images)
+	}
+
+	// Items with every field aren't fetched; a failed fetch keeps the item
+	if completeFetched.Load() {
+		t.Error("fetched the page of an item that needed nothing from it")
+	}
+	if articles[2].Title != "Broken" || articles[2].Summary != "" {
+		t.Errorf("item with a missing page = %+v, want it unchanged", articles[2])
+	}
+}
+
+func TestFetchOpenGraphDisabled(t *testing.T) {
+	var fetched atomic.Bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/@user/mag" {
+			fetched.Store(true)
+		}
+		w.Write([]byte(`<html><body><article class="item"><h3>Title</h3><a href="https://news.example.com/a">Read</a></article></body></html>`))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if fetched.Load() {
+		t.Error("fetched an article page without FetchOpenGraph")
+	}
+}
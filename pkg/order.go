@@ -0,0 +1,51 @@
+package pkg
+
+import "sort"
+
+// ArticleOrder selects the final sort applied to scraped articles
+type ArticleOrder string
+
+const (
+	// OrderNone keeps articles in the order their pages finished
+	OrderNone ArticleOrder = ""
+	// OrderByID sorts articles by ID
+	OrderByID ArticleOrder = "id"
+	// OrderByURL sorts articles by URL, then ID
+	OrderByURL ArticleOrder = "url"
+)
+
+// keepMostRecent returns the n newest articles, newest first. Articles
+// with a zero Date sort after every dated one, in their original order.
+func keepMostRecent(articles []Article, n int) []Article {
+	sort.SliceStable(articles, func(i, j int) bool {
+		a, b := articles[i], articles[j]
+		aDated, bDated := !a.Date.IsZero(), !b.Date.IsZero()
+		if aDated != bDated {
+			return aDated
+		}
+		return aDated && a.Date.After(b.Date)
+	})
+	if len(articles) > n {
+		articles = articles[:n]
+	}
+	return articles
+}
+
+// sortArticles sorts articles in place by order and returns them. Ties are
+// broken by ID, then title, so the result doesn't depend on input order.
+func sortArticles(articles []Article, order ArticleOrder) []Article {
+	if order == OrderNone {
+		return articles
+	}
+	sort.SliceStable(articles, func(i, j int) bool {
+		a, b := articles[i], articles[j]
+		if order == OrderByURL && a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Title < b.Title
+	})
+	return articles
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOrderMakesExportsReproducible(t *testing.T) {
+	// Pages finish in a different order on each run
+	var run int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		magazine := r.URL.Path[strings.LastIndex(r.URL.Path, "-")+1:]
+		delay := time.Duration(magazine[0]-'a') * 30 * time.Millisecond
+		if atomic.LoadInt32(&run)%2 == 1 {
+			delay = 90*time.Millisecond - delay
+		}
+		time.Sleep(delay)
+		fmt.Fprintf(w, `<html><body>
+<article class="item"><h3>%[1]s one</h3><a href="https://example.com/%[1]s/1">Read</a></article>
+<article class="item"><h3>%[1]s two</h3><a href="https://example.com/%[1]s/2">Read</a></article>
+</body></html>`, magazine)
+	})
+
+	config := DefaultConfig()
+	config.RequestsPerSecond = 100
+	config.Order = OrderByURL
+	config.Now = func() time.Time { return time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC) }
+	scraper := newFixtureScraper(t, config, handler)
+	urls := []string{"https://flipboard.com/@user/m-a", "https://flipboard.com/@user/m-b", "https://flipboard.com/@user/m-c"}
+
+	var exports [][]byte
+	for i := 0; i < 2; i++ {
+		atomic.StoreInt32(&run, int32(i))
+		articles, err := scraper.ScrapeURLs(context.Background(), urls)
+		if err != nil {
+			t.Fatalf("run %d: ScrapeURLs() error = %v", i+1, err)
+		}
+		path := filepath.Join(t.TempDir(), "articles.csv")
+		if err := NewCSVExporter(path).Export(articles); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exports = append(exports, data)
+	}
+
+	if !bytes.Equal(exports[0], exports[1]) {
+		t.Errorf("exports differ between runs:\n%s\n---\n%s", exports[0], exports[1])
+	}
+	if urls := readCSVColumn(t, writeTemp(t, exports[0]), "URL"); urls[0] != "https://example.com/a/1" || urls[5] != "https://example.com/c/2" {
+		t.Errorf("articles not sorted by URL: %v", urls)
+	}
+}
+
+// writeTemp writes data to a temporary CSV file and returns its path
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSortArticles(t *testing.T) {
+	articles := []Article{
+		{ID: "3", URL: "https://b.example"},
+		{ID: "1", URL: "https://c.example"},
+		{ID: "2", URL: "https://a.example"},
+	}
+
+	byID := sortArticles(append([]Article(nil), articles...), OrderByID)
+	if got := byID[0].ID + byID[1].ID + byID[2].ID; got != "123" {
+		t.Errorf("OrderByID gave IDs %s, want 123", got)
+	}
+	byURL := sortArticles(append([]Article(nil), articles...), OrderByURL)
+	if got := byURL[0].ID + byURL[1].ID + byURL[2].ID; got != "231" {
+		t.Errorf("OrderByURL gave IDs %s, want 231", got)
+	}
+	none := sortArticles(append([]Article(nil), articles...), OrderNone)
+	if got := none[0].ID + none[1].ID + none[2].ID; got != "312" {
+		t.Errorf("OrderNone reordered articles: %s", got)
+	}
+}
+
+func TestMaxRecentPerMagazineKeepsNewest(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Undated</h3><a href="https://example.com/undated">Read</a></article>
+<article class="item"><h3>Three days</h3><a href="https://example.com/3d">Read</a><span class="timestamp">3d ago</span></article>
+<article class="item"><h3>Five hours</h3><a href="https://example.com/5h">Read</a><span class="timestamp">5h ago</span></article>
+<article class="item"><h3>One week</h3><a href="https://example.com/1w">Read</a><span class="timestamp">1w ago</span></article>
+<article class="item"><h3>Ten minutes</h3><a href="https://example.com/10m">Read</a><span class="timestamp">10m ago</span></article>
+</body></html>`
+	var dispatched atomic.Int32
+	config := DefaultConfig()
+	config.MaxRecentPerMagazine = 3
+	config.Now = func() time.Time { return time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC) }
+	config.OnArticle = func(Article) { dispatched.Add(1) }
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/recent")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	var titles []string
+	for _, article := range articles {
+		titles = append(titles, article.Title)
+	}
+	if got, want := strings.Join(titles, ", "), "Ten minutes, Five hours, Three days"; got != want {
+		t.Errorf("kept %s, want %s", got, want)
+	}
+	if got := dispatched.Load(); got != 3 {
+		t.Errorf("OnArticle saw %d articles, want only the 3 kept", got)
+	}
+}
+
+func TestKeepMostRecentPutsUndatedLast(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	articles := []Article{
+		{Title: "undated first"},
+		{Title: "old", Date: now.Add(-48 * time.Hour), DatePrecision: DateApproximate},
+		{Title: "undated"},
+	}
+	kept := keepMostRecent(articles, 2)
+	if len(kept) != 2 || kept[0].Title != "old" || kept[1].Title != "undated first" {
+		t.Errorf("keepMostRecent() = %+v", kept)
+	}
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// URLOverride customizes how one magazine is scraped. Unset fields keep the
+// scraper's configuration.
+type URLOverride struct {
+	// Selectors replaces the base selectors field by field; empty fields
+	// keep the base chain
+	Selectors SelectorConfig
+	// ExtractComments, ExtractRelated and ExtractFlippedBy override the base
+	// settings when non-nil
+	ExtractComments  *bool
+	ExtractRelated   *bool
+	ExtractFlippedBy *bool
+	// MaxRecentPerMagazine overrides the base setting when non-nil
+	MaxRecentPerMagazine *int
+	// RequestsPerSecond, when positive, paces this magazine's page requests
+	// on top of the scraper's own limits, so it can slow a magazine down
+	// but not speed it past them
+	RequestsPerSecond float64
+}
+
+// apply returns base with the override merged onto it
+func (o URLOverride) apply(base ScraperConfig) ScraperConfig {
+	config := base
+	config.Selectors = base.Selectors.overlay(o.Selectors)
+	if o.ExtractComments != nil {
+		config.ExtractComments = *o.ExtractComments
+	}
+	if o.ExtractRelated != nil {
+		config.ExtractRelated = *o.ExtractRelated
+	}
+	if o.ExtractFlippedBy != nil {
+		config.ExtractFlippedBy = *o.ExtractFlippedBy
+	}
+	if o.MaxRecentPerMagazine != nil {
+		config.MaxRecentPerMagazine = *o.MaxRecentPerMagazine
+	}
+	return config
+}
+
+// canonicalOverrides keys overrides by canonical magazine URL, so they
+// match however the URL was written
+func canonicalOverrides(overrides map[string]URLOverride) map[string]URLOverride {
+	if len(overrides) == 0 {
+		return nil
+	}
+	canonical := make(map[string]URLOverride, len(overrides))
+	for rawURL, override := range overrides {
+		canonical[CanonicalMagazineURL(rawURL)] = override
+	}
+	return canonical
+}
+
+// newOverrideLimiters creates a limiter for each override setting
+// RequestsPerSecond, keyed like overrides, or returns nil when none does
+func newOverrideLimiters(overrides map[string]URLOverride) map[string]*rate.Limiter {
+	var limiters map[string]*rate.Limiter
+	for magazineURL, override := range overrides {
+		if override.RequestsPerSecond <= 0 {
+			continue
+		}
+		if limiters == nil {
+			limiters = make(map[string]*rate.Limiter)
+		}
+		limiters[magazineURL] = rate.NewLimiter(rate.Limit(override.RequestsPerSecond), 1)
+	}
+	return limiters
+}
+
+// waitPage is wait for pageURL, a page of the magazine at url, first
+// waiting for the magazine's own rate when its override sets one
+func (s *MagazineScraper) waitPage(ctx context.Context, url, pageURL string) error {
+	if limiter := s.urlRates[url]; limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return s.wait(ctx, pageURL)
+}
+
+// configFor returns the configuration to scrape magazineURL with: the
+// scraper's own, with any override for that URL merged on
+func (s *MagazineScraper) configFor(magazineURL string) ScraperConfig {
+	if override, ok := s.overrides[magazineURL]; ok {
+		return override.apply(s.config)
+	}
+	return s.config
+}
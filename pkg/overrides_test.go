@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestURLOverridesApplyPerMagazine(t *testing.T) {
+	// Both magazines serve the same page, which has standard items and
+	// items in a custom layout
+	page := `<html><body>
+<article class="item"><h3>Standard</h3><a href="https://example.com/standard">Read</a></article>
+<div class="card"><h4>Custom</h4><a href="https://example.com/custom">Read</a></div>
+</body></html>`
+	handler := fixtureHandler(page)
+
+	config := DefaultConfig()
+	config.RequestsPerSecond = 100
+	config.URLOverrides = map[string]URLOverride{
+		// Written as a variant of the canonical URL on purpose
+		"https://www.flipboard.com/@user/custom/": {
+			Selectors: SelectorConfig{Item: "div.card", Title: []string{"h4"}},
+		},
+	}
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, err := scraper.ScrapeURLs(context.Background(), []string{
+		"https://flipboard.com/@user/default",
+		"https://flipboard.com/@user/custom",
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+
+	byMagazine := make(map[string][]string)
+	for _, article := range articles {
+		byMagazine[article.SourceMagazineURL] = append(byMagazine[article.SourceMagazineURL], article.Title)
+	}
+	if got := strings.Join(byMagazine["https://flipboard.com/@user/default"], ","); got != "Standard" {
+		t.Errorf("default magazine extracted %q, want Standard", got)
+	}
+	if got := strings.Join(byMagazine["https://flipboard.com/@user/custom"], ","); got != "Custom" {
+		t.Errorf("overridden magazine extracted %q, want Custom", got)
+	}
+}
+
+func TestURLOverrideApply(t *testing.T) {
+	enabled := true
+	base := DefaultConfig()
+	config := URLOverride{
+		Selectors:       SelectorConfig{Summary: []string{".dek"}},
+		ExtractComments: &enabled,
+	}.apply(base)
+
+	if !config.ExtractComments || config.ExtractRelated {
+		t.Errorf("extract flags = comments %v, related %v", config.ExtractComments, config.ExtractRelated)
+	}
+	if got := config.Selectors.Summary; len(got) != 1 || got[0] != ".dek" {
+		t.Errorf("Summary selectors = %v, want the override", got)
+	}
+	if got := config.Selectors.Title; len(got) != len(base.Selectors.Title) {
+		t.Errorf("Title selectors = %v, want the base chain", got)
+	}
+	if base.ExtractComments {
+		t.Error("apply modified the base config")
+	}
+}
+
+func TestURLOverrideRequestsPerSecond(t *testing.T) {
+	// Each magazine has three pages
+	var (
+		mu       sync.Mutex
+		requests = make(map[string][]time.Time)
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path] = append(requests[r.URL.Path], time.Now())
+		mu.Unlock()
+		page := r.URL.Query().Get("page")
+		next := map[string]string{"": "?page=2", "2": "?page=3", "3": ""}[page]
+		fmt.Fprintf(w, `<html><body>
+<article class="item"><h3>Page %s</h3><a href="https://example.com%s/%s">Read</a></article>
+<link rel="next" href="%s%s">
+</body></html>`, page, r.URL.Path, page, r.URL.Path, next)
+	})
+
+	const interval = 100 * time.Millisecond
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxPages = 3
+	config.URLOverrides = map[string]URLOverride{
+		"https://flipboard.com/@user/slow": {RequestsPerSecond: float64(time.Second / interval)},
+	}
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURLs(context.Background(), []string{
+		"https://flipboard.com/@user/fast",
+		"https://flipboard.com/@user/slow",
+	}); err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for path, times := range requests {
+		if len(times) != 3 {
+			t.Errorf("%s fetched %d pages, want 3", path, len(times))
+			continue
+		}
+		elapsed := times[2].Sub(times[0])
+		switch path {
+		case "/@user/slow":
+			if elapsed < 2*interval-20*time.Millisecond {
+				t.Errorf("overridden magazine fetched 3 pages in %v, want at least %v", elapsed, 2*interval)
+			}
+		case "/@user/fast":
+			if elapsed >= interval {
+				t.Errorf("magazine without a rate override fetched 3 pages in %v", elapsed)
+			}
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("requested paths %v, want both magazines", requests)
+	}
+}
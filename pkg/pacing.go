@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// latencySmoothing is the weight given to each new latency sample in the
+// exponentially weighted moving average
+const latencySmoothing = 0.3
+
+// adaptiveLimiter paces requests per host based on observed response
+// latency: a host that answers slowly is sent requests less often. Each
+// host's rate is kept within [min, max] requests per second.
+type adaptiveLimiter struct {
+	mu    sync.Mutex
+	min   float64
+	max   float64
+	hosts map[string]*hostPacer
+}
+
+// hostPacer tracks the limiter and smoothed latency of a single host
+type hostPacer struct {
+	limiter *rate.Limiter
+	latency time.Duration
+}
+
+// newAdaptiveLimiter creates a limiter bounded to [min, max] requests per second
+func newAdaptiveLimiter(min, max float64) *adaptiveLimiter {
+	if max < min {
+		max = min
+	}
+	return &adaptiveLimiter{
+		min:   min,
+		max:   max,
+		hosts: make(map[string]*hostPacer),
+	}
+}
+
+// pacer returns the pacer for host, creating one at the maximum rate
+func (l *adaptiveLimiter) pacer(host string) *hostPacer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.hosts[host]
+	if !ok {
+		p = &hostPacer{limiter: rate.NewLimiter(rate.Limit(l.max), 1)}
+		l.hosts[host] = p
+	}
+	return p
+}
+
+// Wait blocks until a request to host is allowed
+func (l *adaptiveLimiter) Wait(ctx context.Context, host string) error {
+	return l.pacer(host).limiter.Wait(ctx)
+}
+
+// Observe records the latency of a request to host and adjusts its rate so
+// that requests are spaced at least one smoothed latency apart
+func (l *adaptiveLimiter) Observe(host string, latency time.Duration) {
+	p := l.pacer(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if p.latency == 0 {
+		p.latency = latency
+	} else {
+		p.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(p.latency))
+	}
+
+	target := l.max
+	if p.latency > 0 {
+		target = float64(time.Second) / float64(p.latency)
+	}
+	if target < l.min {
+		target = l.min
+	}
+	if target > l.max {
+		target = l.max
+	}
+	p.limiter.SetLimit(rate.Limit(target))
+}
+
+// Limit reports the current rate for host in requests per second
+func (l *adaptiveLimiter) Limit(host string) float64 {
+	return float64(l.pacer(host).limiter.Limit())
+}
+
+// hostRateLimiters paces each class of hosts at its own rate. A class is a
+// host pattern as understood by path.Match, such as "flipboard.com" or
+// "*.nytimes.com"; every host matching a pattern shares one limiter.
+type hostRateLimiters struct {
+	patterns []string // most specific first
+	limiters map[string]*rate.Limiter
+}
+
+// newHostRateLimiters creates a limiter per pattern in rates, or returns
+// nil when rates is empty
+func newHostRateLimiters(rates map[string]float64) *hostRateLimiters {
+	if len(rates) == 0 {
+		return nil
+	}
+	l := &hostRateLimiters{limiters: make(map[string]*rate.Limiter, len(rates))}
+	for pattern, rps := range rates {
+		l.patterns = append(l.patterns, pattern)
+		l.limiters[pattern] = rate.NewLimiter(rate.Limit(rps), 1)
+	}
+	// Prefer exact hosts over wildcards, then longer patterns
+	sort.Slice(l.patterns, func(i, j int) bool {
+		pi, pj := l.patterns[i], l.patterns[j]
+		wi, wj := strings.Contains(pi, "*"), strings.Contains(pj, "*")
+		if wi != wj {
+			return !wi
+		}
+		if len(pi) != len(pj) {
+			return len(pi) > len(pj)
+		}
+		return pi < pj
+	})
+	return l
+}
+
+// limiterFor returns the limiter of the first pattern matching host, or
+// nil when no pattern matches
+func (l *hostRateLimiters) limiterFor(host string) *rate.Limiter {
+	if l == nil {
+		return nil
+	}
+	host = strings.ToLower(host)
+	for _, pattern := range l.patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return l.limiters[pattern]
+		}
+	}
+	return nil
+}
+
+// errLimiterClosed is returned by Wait on a closed tickerLimiter
+var errLimiterClosed = errors.New("rate limiter closed")
+
+// tickerLimiter releases requests on the ticks of a time.Ticker, spacing
+// them exactly one interval apart. Unlike a token bucket it never lets a
+// backlog of requests through in a burst.
+type tickerLimiter struct {
+	ticker    *time.Ticker
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newTickerLimiter creates a limiter allowing one request every
+// 1/requestsPerSecond seconds
+func newTickerLimiter(requestsPerSecond float64) *tickerLimiter {
+	interval := time.Duration(float64(time.Second) / requestsPerSecond)
+	return &tickerLimiter{
+		ticker: time.NewTicker(interval),
+		done:   make(chan struct{}),
+	}
+}
+
+// Wait blocks until the next tick, failing once the limiter is closed
+func (l *tickerLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-l.done:
+		return errLimiterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the ticker and releases any pending Wait
+func (l *tickerLimiter) Close() {
+	l.closeOnce.Do(func() {
+		l.ticker.Stop()
+		close(l.done)
+	})
+}
+
+// hostOf returns the host of rawURL, or rawURL itself if it can't be parsed
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
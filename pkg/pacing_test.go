@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterWidensForSlowHost(t *testing.T) {
+	limiter := newAdaptiveLimiter(0.5, 10)
+	host := "flipboard.com"
+
+	if got := limiter.Limit(host); got != 10 {
+		t.Fatalf("initial limit = %v, want max 10", got)
+	}
+
+	// The host gets slower with every response
+	previous := limiter.Limit(host)
+	for _, latency := range []time.Duration{100, 300, 600, 1200, 2400} {
+		limiter.Observe(host, latency*time.Millisecond)
+		current := limiter.Limit(host)
+		if current > previous {
+			t.Errorf("limit rose from %v to %v as latency grew to %v", previous, current, latency*time.Millisecond)
+		}
+		previous = current
+	}
+	if previous >= 10 {
+		t.Errorf("limit = %v, expected pacing to widen below the max", previous)
+	}
+
+	// Very slow responses are clamped to the minimum rate
+	for i := 0; i < 20; i++ {
+		limiter.Observe(host, 10*time.Second)
+	}
+	if got := limiter.Limit(host); got != 0.5 {
+		t.Errorf("limit = %v, want min 0.5", got)
+	}
+
+	// Other hosts are paced independently
+	if got := limiter.Limit("example.com"); got != 10 {
+		t.Errorf("unrelated host limit = %v, want 10", got)
+	}
+}
+
+func TestTickerLimiterEvenSpacing(t *testing.T) {
+	const interval = 25 * time.Millisecond
+	limiter := newTickerLimiter(float64(time.Second / interval))
+
+	var ticks []time.Time
+	for i := 0; i < 6; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+		ticks = append(ticks, time.Now())
+	}
+
+	for i := 1; i < len(ticks); i++ {
+		gap := ticks[i].Sub(ticks[i-1])
+		if gap < interval-10*time.Millisecond || gap > interval+10*time.Millisecond {
+			t.Errorf("gap %d = %v, want about %v", i, gap, interval)
+		}
+	}
+}
+
+func TestTickerLimiterClose(t *testing.T) {
+	limiter := newTickerLimiter(0.1)
+	waited := make(chan error, 1)
+	go func() { waited <- limiter.Wait(context.Background()) }()
+
+	limiter.Close()
+	limiter.Close()
+	select {
+	case err := <-waited:
+		if err != errLimiterClosed {
+			t.Errorf("Wait() error = %v, want %v", err, errLimiterClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait() still blocked after Close")
+	}
+}
+
+func TestSmoothPacingUsesTicker(t *testing.T) {
+	config := DefaultConfig()
+	config.SmoothPacing = true
+	scraper := mustNewScraper(t, config)
+	if _, ok := scraper.limiter.(*tickerLimiter); !ok {
+		t.Errorf("limiter is %T, want *tickerLimiter", scraper.limiter)
+	}
+}
+
+func TestHostRatesLimitEachClass(t *testing.T) {
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.HostRates = map[string]float64{
+		"flipboard.com": 50,
+		"*.example.com": 10,
+	}
+	scraper := mustNewScraper(t, config)
+
+	// With a burst of 1, n requests take (n-1)/rate
+	elapsed := func(url string, n int) time.Duration {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := scraper.wait(context.Background(), url); err != nil {
+				t.Fatalf("wait(%s) error = %v", url, err)
+			}
+		}
+		return time.Since(start)
+	}
+
+	tests := []struct {
+		url      string
+		min, max time.Duration
+	}{
+		{"https://flipboard.com/@user/m", 60 * time.Millisecond, 150 * time.Millisecond},
+		{"https://news.example.com/story", 300 * time.Millisecond, 450 * time.Millisecond},
+		{"https://other.org/story", 0, 30 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := elapsed(tt.url, 4); got < tt.min || got > tt.max {
+			t.Errorf("4 requests to %s took %v, want between %v and %v", tt.url, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestHostRateLimitersPreferExactHost(t *testing.T) {
+	limiters := newHostRateLimiters(map[string]float64{
+		"*.flipboard.com":     1,
+		"cdn.flipboard.com":   5,
+		"*.cdn.flipboard.com": 9,
+	})
+	if got := limiters.limiterFor("cdn.flipboard.com").Limit(); got != 5 {
+		t.Errorf("cdn.flipboard.com limit = %v, want the exact pattern's 5", got)
+	}
+	if got := limiters.limiterFor("a.cdn.flipboard.com").Limit(); got != 9 {
+		t.Errorf("a.cdn.flipboard.com limit = %v, want the longer wildcard's 9", got)
+	}
+	if limiters.limiterFor("flipboard.org") != nil {
+		t.Error("expected no limiter for an unmatched host")
+	}
+}
+
+func TestAdaptivePacingObservesEachRequest(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `<html><body>
+<article class="item"><h3>Story %d</h3><a href="https://example.com/%d">Read</a></article>
+<a rel="next" href="/@user/paged?page=%d">More</a>
+</body></html>`, page, page, page+1)
+	})
+	config := DefaultConfig()
+	config.MaxPages = 3
+	config.AdaptivePacing = true
+	config.MaxRequestsPerSecond = 5
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/paged"})
+	if err != nil || len(articles) != 3 {
+		t.Fatalf("got %d articles, error = %v; want 3 pages scraped", len(articles), err)
+	}
+
+	// The pacer's own 200ms waits between pages aren't counted as latency
+	if got := scraper.pacer.Limit("flipboard.com"); got != 5 {
+		t.Errorf("limit = %v, want the max 5 for a fast host", got)
+	}
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+)
+
+// pauseGate holds back new requests while paused. Requests already in
+// flight finish normally, and waiting URLs stay queued until resumed.
+type pauseGate struct {
+	mu      sync.Mutex
+	resumed chan struct{} // closed while running
+}
+
+// newPauseGate creates a gate in the running state
+func newPauseGate() *pauseGate {
+	resumed := make(chan struct{})
+	close(resumed)
+	return &pauseGate{resumed: resumed}
+}
+
+// pause stops new requests until resume is called
+func (g *pauseGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.resumed:
+		g.resumed = make(chan struct{})
+	default:
+		// Already paused
+	}
+}
+
+// resume lets waiting and new requests proceed
+func (g *pauseGate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.resumed:
+		// Already running
+	default:
+		close(g.resumed)
+	}
+}
+
+// wait blocks while the gate is paused
+func (g *pauseGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	resumed := g.resumed
+	g.mu.Unlock()
+
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause stops the scraper from starting new requests, e.g. when a host
+// complains. Requests already in flight complete; queued URLs wait until
+// Resume is called. The run's Timeout keeps counting while paused.
+func (s *MagazineScraper) Pause() {
+	s.paused.pause()
+}
+
+// Resume lets a paused scraper continue with its queued URLs
+func (s *MagazineScraper) Resume() {
+	s.paused.resume()
+}
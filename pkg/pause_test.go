@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	var requests int32
+	page := `<html><body><article class="item"><h3>Item</h3><a href="https://example.com/1">Read</a></article></body></html>`
+
+	var scraper *MagazineScraper
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Pause as soon as the first page is being served
+		if atomic.AddInt32(&requests, 1) == 1 {
+			scraper.Pause()
+		}
+		w.Write([]byte(page))
+	})
+	config := DefaultConfig()
+	config.ConcurrentRequests = 1
+	config.RequestsPerSecond = 100
+	scraper = newFixtureScraper(t, config, handler)
+
+	urls := make([]string, 4)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://flipboard.com/@user/magazine-%d", i)
+	}
+
+	type result struct {
+		articles []Article
+		err      error
+	}
+	done := make(chan result)
+	go func() {
+		articles, err := scraper.ScrapeURLs(context.Background(), urls)
+		done <- result{articles, err}
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("%d requests were made while paused, want 1", got)
+	}
+	select {
+	case <-done:
+		t.Fatal("ScrapeURLs returned while paused")
+	default:
+	}
+
+	scraper.Resume()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			t.Fatalf("ScrapeURLs() error = %v", res.err)
+		}
+		if len(res.articles) != len(urls) {
+			t.Errorf("got %d articles, want %d", len(res.articles), len(urls))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ScrapeURLs did not finish after Resume")
+	}
+	if got := atomic.LoadInt32(&requests); got != int32(len(urls)) {
+		t.Errorf("made %d requests, want %d", got, len(urls))
+	}
+}
+
+func TestPauseGateWaitHonorsContext(t *testing.T) {
+	gate := newPauseGate()
+	gate.pause()
+	gate.pause() // pausing twice must not lose the first channel
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := gate.wait(ctx); err == nil {
+		t.Fatal("wait() returned nil while paused")
+	}
+
+	gate.resume()
+	gate.resume()
+	if err := gate.wait(context.Background()); err != nil {
+		t.Errorf("wait() after resume error = %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetryBackoff is the first retry delay used when RetryBackoff is unset
+const defaultRetryBackoff = time.Second
+
+// retryableStatuses are the responses Flipboard sends when it is overloaded
+// rather than when the request itself is wrong
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// requestError is a failed magazine request, classified by its status code
+type requestError struct {
+	status    int
+	retryable bool
+	// retryAfter is how long the server asked us to wait, when
+	// hasRetryAfter is set
+	retryAfter    time.Duration
+	hasRetryAfter bool
+	err           error
+}
+
+// newRequestError classifies a request that failed with status, reading
+// the server's Retry-After from header when present
+func newRequestError(status int, header http.Header, err error) *requestError {
+	reqErr := &requestError{status: status, retryable: retryableStatuses[status], err: err}
+	reqErr.retryAfter, reqErr.hasRetryAfter = parseRetryAfter(header.Get("Retry-After"), time.Now())
+	return reqErr
+}
+
+// parseRetryAfter reads a Retry-After value, either delta-seconds such as
+// "120" or an HTTP-date, as a duration from now. A date in the past means
+// no wait.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %v", e.status, e.err)
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// isRetryable reports whether err is a transient failure worth retrying
+func isRetryable(err error) bool {
+	var reqErr *requestError
+	return errors.As(err, &reqErr) && reqErr.retryable
+}
+
+// backoffFor returns how long to wait before retry number attempt after
+// err: the server's Retry-After when it sent one, reported by fromServer,
+// otherwise retryDelay
+func backoffFor(err error, base time.Duration, attempt int) (delay time.Duration, fromServer bool) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) && reqErr.hasRetryAfter {
+		return reqErr.retryAfter, true
+	}
+	return retryDelay(base, attempt), false
+}
+
+// retryDelay returns how long to wait before retry number attempt (counting
+// from 0): base doubled per attempt, with the upper half jittered so
+// concurrent workers don't retry in lockstep
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	delay := base << attempt
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleepBeforeRetry waits delay, reporting whether to retry, so a retry never
+// outlives the run's deadline. When our own backoff would outlast ctx it
+// gives up without waiting. A Retry-After from the server (fromServer) is
+// instead cut short to leave a tenth of the remaining time for one last
+// attempt, reported by last; only when no time is left does it give up.
+func sleepBeforeRetry(ctx context.Context, delay time.Duration, fromServer bool) (retry, last bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		switch {
+		case left <= 0:
+			return false, false
+		case left >= delay:
+		case !fromServer:
+			return false, false
+		default:
+			delay, last = left-left/10, true
+		}
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false, false
+	case <-timer.C:
+		return true, last
+	}
+}
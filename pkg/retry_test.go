@@ -0,0 +1,269 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyHandler answers status for the first failures requests, then page
+func flakyHandler(status, failures int, page string) (http.Handler, *atomic.Int32) {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		w.Write([]byte(page))
+	}), &requests
+}
+
+func TestScrapeURLRetriesTransientFailures(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Back</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	handler, requests := flakyHandler(http.StatusServiceUnavailable, 2, page)
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 2
+	config.RetryBackoff = 10 * time.Millisecond
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/flaky")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v, want success on the third attempt", err)
+	}
+	if len(articles) != 1 {
+		t.Errorf("got %d articles, want 1", len(articles))
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+}
+
+func TestScrapeURLRetryLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		maxRetries   int
+		wantRequests int32
+	}{
+		{"retries exhausted", http.StatusServiceUnavailable, 1, 2},
+		{"rate limited", http.StatusTooManyRequests, 2, 3},
+		{"permanent failure", http.StatusNotFound, 2, 1},
+		{"server error", http.StatusInternalServerError, 2, 1},
+		{"retries disabled", http.StatusBadGateway, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, requests := flakyHandler(tt.status, 10, "")
+			config := DefaultConfig()
+			config.RequestsPerSecond = 1000
+			config.MaxRetries = tt.maxRetries
+			config.RetryBackoff = time.Millisecond
+			scraper := newFixtureScraper(t, config, handler)
+
+			_, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/down")
+			if err == nil {
+				t.Fatal("ScrapeURL() error = nil, want the last failure")
+			}
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestScrapeURLRetryRespectsDeadline(t *testing.T) {
+	handler, requests := flakyHandler(http.StatusServiceUnavailable, 10, "")
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 5
+	config.RetryBackoff = time.Hour
+	scraper := newFixtureScraper(t, config, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := scraper.ScrapeURL(ctx, "https://flipboard.com/@user/down")
+	if !isRetryable(err) {
+		t.Errorf("ScrapeURL() error = %v, want the retryable 503", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ScrapeURL took %v, want it to give up rather than wait past the deadline", elapsed)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 0; attempt < 4; attempt++ {
+		ceiling := base << attempt
+		for i := 0; i < 50; i++ {
+			if delay := retryDelay(base, attempt); delay < ceiling/2 || delay > ceiling {
+				t.Fatalf("retryDelay(%v, %d) = %v, want within [%v, %v]", base, attempt, delay, ceiling/2, ceiling)
+			}
+		}
+	}
+	if delay := retryDelay(0, 0); delay < defaultRetryBackoff/2 || delay > defaultRetryBackoff {
+		t.Errorf("retryDelay(0, 0) = %v, want about %v", delay, defaultRetryBackoff)
+	}
+}
+
+func TestRequestErrorClassification(t *testing.T) {
+	cause := errors.New("Service Unavailable")
+	err := newRequestError(http.StatusServiceUnavailable, nil, cause)
+	if !isRetryable(err) || !errors.Is(err, cause) {
+		t.Errorf("503: retryable = %v, unwraps = %v, want both", isRetryable(err), errors.Is(err, cause))
+	}
+	if err.Error() != "request failed with status 503: Service Unavailable" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if isRetryable(newRequestError(http.StatusForbidden, nil, cause)) {
+		t.Error("403 should not be retryable")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"2", 2 * time.Second, true},
+		{" 120 ", 2 * time.Minute, true},
+		{"0", 0, true},
+		{"Sat, 01 Jun 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Saturday, 01-Jun-24 12:01:00 GMT", time.Minute, true},
+		{"Sat, 01 Jun 2024 11:00:00 GMT", 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestScrapeURLHonorsRetryAfter(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Back</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	var mu sync.Mutex
+	var requests []time.Time
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, time.Now())
+		first := len(requests) == 1
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "2")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 1
+	config.RetryBackoff = time.Millisecond
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/limited"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if gap := requests[1].Sub(requests[0]); gap < 2*time.Second {
+		t.Errorf("retried after %v, want at least the 2s Retry-After", gap)
+	}
+}
+
+func TestRetryAfterPastDeadlineRetriesBeforeIt(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Back</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	var mu sync.Mutex
+	var requests []time.Time
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, time.Now())
+		first := len(requests) == 1
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 3
+	scraper := newFixtureScraper(t, config, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := scraper.ScrapeURL(ctx, "https://flipboard.com/@user/limited"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v, want the retry before the deadline to succeed", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if gap := requests[1].Sub(requests[0]); gap < time.Second {
+		t.Errorf("retried after %v, want the wait to run close to the deadline", gap)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("scrape took %v, past the 2s deadline", elapsed)
+	}
+}
+
+func TestRetryAfterPastDeadlineRetriesOnce(t *testing.T) {
+	var requests atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.MaxRetries = 3
+	scraper := newFixtureScraper(t, config, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if _, err := scraper.ScrapeURL(ctx, "https://flipboard.com/@user/limited"); !isRetryable(err) {
+		t.Errorf("ScrapeURL() error = %v, want the retryable 429", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want the first and one last attempt before the deadline", n)
+	}
+}
+
+func TestSleepBeforeRetryPastDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	start := time.Now()
+	if retry, _ := sleepBeforeRetry(ctx, time.Minute, true); retry {
+		t.Error("sleepBeforeRetry() retries with the deadline already passed")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("sleepBeforeRetry() waited %v with no time left", elapsed)
+	}
+}
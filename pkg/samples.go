@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// rejectSampler writes the HTML of items dropped during extraction, up to a
+// fixed number per scraper, so under-delivering selectors can be inspected
+type rejectSampler struct {
+	mu     sync.Mutex
+	output io.Writer
+	left   int
+}
+
+// newRejectSampler returns a sampler writing up to max items to output, or
+// nil when sampling is off
+func newRejectSampler(max int, output io.Writer) *rejectSampler {
+	if max <= 0 || output == nil {
+		return nil
+	}
+	return &rejectSampler{output: output, left: max}
+}
+
+// sample writes item's HTML, prefixed with a comment naming the page and the
+// reason it was dropped. It does nothing once the limit is reached.
+func (r *rejectSampler) sample(pageURL, reason string, item *goquery.Selection) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.left == 0 {
+		return
+	}
+	html, err := goquery.OuterHtml(item)
+	if err != nil {
+		return
+	}
+	r.left--
+	fmt.Fprintf(r.output, "<!-- %s: %s -->\n%s\n\n", maskURL(pageURL), reason, html)
+}
@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRejectedSamplesCaptureDroppedItems(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Kept</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><a href="https://example.com/untitled-1">Read</a></article>
+<article class="item"><h3>   </h3><a href="https://example.com/untitled-2">Read</a></article>
+<article class="item"><a href="https://example.com/untitled-3">Read</a></article>
+</body></html>`
+	var samples bytes.Buffer
+	config := DefaultConfig()
+	config.Debug = true
+	config.DebugOutput = io.Discard
+	config.RejectedSamples = 2
+	config.RejectedSamplesOutput = &samples
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/samples")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1", len(articles))
+	}
+
+	written := samples.String()
+	if got := strings.Count(written, "<article"); got != 2 {
+		t.Errorf("wrote %d samples, want the limit of 2:\n%s", got, written)
+	}
+	for _, want := range []string{"<!-- https://flipboard.com/@user/samples: empty title -->", "https://example.com/untitled-1", "https://example.com/untitled-2"} {
+		if !strings.Contains(written, want) {
+			t.Errorf("samples missing %q:\n%s", want, written)
+		}
+	}
+	if strings.Contains(written, "Kept") {
+		t.Errorf("samples include a valid item:\n%s", written)
+	}
+}
+
+func TestRejectedSamplesNeedDebug(t *testing.T) {
+	page := `<html><body><article class="item"><a href="https://example.com/1">Read</a></article></body></html>`
+	var samples bytes.Buffer
+	config := DefaultConfig()
+	config.RejectedSamples = 5
+	config.RejectedSamplesOutput = &samples
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/samples")
+	if samples.Len() > 0 {
+		t.Errorf("expected no samples without Debug, got:\n%s", samples.String())
+	}
+}
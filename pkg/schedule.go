@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// Scheduler runs a function repeatedly, one run at a time. A run that takes
+// longer than Interval delays the next one instead of overlapping it.
+type Scheduler struct {
+	// Interval is the nominal time between the starts of consecutive runs.
+	// Zero starts the next run as soon as the previous one finishes.
+	Interval time.Duration
+	// MinInterval is the shortest time allowed between the starts of
+	// consecutive runs, however quickly a run finishes
+	MinInterval time.Duration
+	// Run performs a single run
+	Run func(ctx context.Context) error
+	// OnError, when set, is called with the error of each failed run
+	OnError func(error)
+}
+
+// Start runs immediately and then on schedule until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) error {
+	for {
+		start := time.Now()
+		if err := s.Run(ctx); err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+
+		timer := time.NewTimer(time.Until(s.nextStart(start, time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// nextStart returns when the run after one that started at start and
+// finished at end may begin
+func (s *Scheduler) nextStart(start, end time.Time) time.Time {
+	next := start.Add(s.Interval)
+	if next.Before(end) {
+		next = end
+	}
+	if earliest := start.Add(s.MinInterval); next.Before(earliest) {
+		next = earliest
+	}
+	return next
+}
@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerNeverOverlaps(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+		overlap bool
+		starts  []time.Time
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := &Scheduler{
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			if running > 1 {
+				overlap = true
+			}
+			starts = append(starts, time.Now())
+			if len(starts) == 3 {
+				cancel()
+			}
+			mu.Unlock()
+
+			// Much longer than the interval
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		},
+	}
+	scheduler.Start(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if overlap {
+		t.Error("Runs overlapped")
+	}
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 50*time.Millisecond {
+			t.Errorf("run %d started %v after the previous, before it could have finished", i, gap)
+		}
+	}
+}
+
+func TestSchedulerMinInterval(t *testing.T) {
+	var starts []time.Time
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := &Scheduler{
+		MinInterval: 30 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			starts = append(starts, time.Now())
+			if len(starts) == 3 {
+				cancel()
+			}
+			return nil // finishes instantly
+		},
+	}
+	scheduler.Start(ctx)
+
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 30*time.Millisecond {
+			t.Errorf("run %d started %v after the previous, want at least 30ms", i, gap)
+		}
+	}
+}
+
+func TestScheduledRunsReuseScraper(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	var connections int32
+	server := httptest.NewUnstartedServer(fixtureHandler(page))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen []string
+	config := DefaultConfig()
+	config.RequestsPerSecond = 100
+	config.OnArticle = func(a Article) { seen = append(seen, a.Title) }
+	scraper := mustNewScraper(t, config)
+	scraper.collector.WithTransport(&rewriteTransport{target: target, base: &http.Transport{}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var counts []int
+	scheduler := &Scheduler{
+		MinInterval: 20 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			seen = nil
+			articles, err := scraper.ScrapeURLs(ctx, []string{"https://flipboard.com/@user/warm"})
+			counts = append(counts, len(articles), len(seen))
+			if len(counts) == 4 {
+				cancel()
+			}
+			return err
+		},
+		OnError: func(err error) {
+			if ctx.Err() == nil {
+				t.Errorf("run failed: %v", err)
+			}
+		},
+	}
+	scheduler.Start(ctx)
+
+	// Each run sees only its own articles, and callbacks from the first
+	// run don't fire again in the second
+	if fmt.Sprint(counts) != "[2 2 2 2]" {
+		t.Errorf("articles and OnArticle calls per run = %v, want [2 2 2 2]", counts)
+	}
+	if connections != 1 {
+		t.Errorf("opened %d connections across runs, want 1", connections)
+	}
+}
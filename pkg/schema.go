@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SchemaViolation describes a single field of an article that breaks the
+// export data contract
+type SchemaViolation struct {
+	Index  int
+	Field  string
+	Reason string
+}
+
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("article %d: %s %s", v.Index, v.Field, v.Reason)
+}
+
+// SchemaError aggregates every violation found during validation
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return fmt.Sprintf("%d schema violation(s): %s", len(e.Violations), strings.Join(lines, "; "))
+}
+
+// ValidateArticles checks exported articles against the expected schema:
+// every article needs a title and an absolute http(s) URL, and any image,
+// related or publisher logo URLs must be absolute too. It returns a
+// *SchemaError listing all violations, or nil when the data conforms.
+func ValidateArticles(articles []Article) error {
+	var violations []SchemaViolation
+	for i, article := range articles {
+		if strings.TrimSpace(article.Title) == "" {
+			violations = append(violations, SchemaViolation{i, "title", "is empty"})
+		}
+		if reason := checkAbsoluteURL(article.URL); reason != "" {
+			violations = append(violations, SchemaViolation{i, "url", reason})
+		}
+		for _, image := range article.Images {
+			if reason := checkAbsoluteURL(image); reason != "" {
+				violations = append(violations, SchemaViolation{i, "images", reason})
+			}
+		}
+		for _, related := range article.RelatedURLs {
+			if reason := checkAbsoluteURL(related); reason != "" {
+				violations = append(violations, SchemaViolation{i, "related_urls", reason})
+			}
+		}
+		if article.PublisherLogoURL != "" {
+			if reason := checkAbsoluteURL(article.PublisherLogoURL); reason != "" {
+				violations = append(violations, SchemaViolation{i, "publisher_logo_url", reason})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+// checkAbsoluteURL returns why raw isn't an absolute http(s) URL, or ""
+func checkAbsoluteURL(raw string) string {
+	if raw == "" {
+		return "is empty"
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Sprintf("is not parseable: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Sprintf("has unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "has no host"
+	}
+	return ""
+}
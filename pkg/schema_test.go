@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateArticles(t *testing.T) {
+	if err := ValidateArticles(sampleArticles()); err != nil {
+		t.Fatalf("ValidateArticles() on valid data error = %v", err)
+	}
+
+	malformed := []Article{
+		{Title: "Fine", URL: "https://example.com/ok"},
+		{Title: "  ", URL: "/relative/path"},
+		{Title: "Bad image", URL: "https://example.com/img", Images: []string{"::not a url"}},
+	}
+	err := ValidateArticles(malformed)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected *SchemaError, got %v", err)
+	}
+
+	want := []SchemaViolation{
+		{Index: 1, Field: "title"},
+		{Index: 1, Field: "url"},
+		{Index: 2, Field: "images"},
+	}
+	if len(schemaErr.Violations) != len(want) {
+		t.Fatalf("got %d violations, want %d: %v", len(schemaErr.Violations), len(want), err)
+	}
+	for i, w := range want {
+		got := schemaErr.Violations[i]
+		if got.Index != w.Index || got.Field != w.Field {
+			t.Errorf("violation %d = %+v, want index %d field %s", i, got, w.Index, w.Field)
+		}
+	}
+}
@@ -2,10 +2,80 @@ package pkg
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// rewriteTransport sends every request to a local test server while keeping
+// the original path, so fixtures can be served for flipboard.com URLs
+type rewriteTransport struct {
+	target *url.URL
+	// base performs the rewritten request; nil uses http.DefaultTransport
+	base http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rewritten := req.Clone(req.Context())
+	rewritten.URL.Scheme = t.target.Scheme
+	rewritten.URL.Host = t.target.Host
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(rewritten)
+	if err != nil {
+		return nil, err
+	}
+	// Report the original URL so relative links resolve against flipboard.com
+	resp.Request = req
+	return resp, nil
+}
+
+// newFixtureScraper returns a scraper whose requests are answered by handler
+func newFixtureScraper(t *testing.T, config ScraperConfig, handler http.Handler) *MagazineScraper {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+
+	scraper := mustNewScraper(t, config)
+	scraper.collector.WithTransport(&rewriteTransport{target: target})
+	return scraper
+}
+
+// mustNewScraper creates a scraper, failing the test on an invalid config
+func mustNewScraper(t *testing.T, config ScraperConfig) *MagazineScraper {
+	t.Helper()
+	scraper, err := NewMagazineScraper(config)
+	if err != nil {
+		t.Fatalf("NewMagazineScraper() error = %v", err)
+	}
+	return scraper
+}
+
+// fixtureHandler serves the given HTML for every request
+func fixtureHandler(html string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(html))
+	})
+}
+
 func TestCleanText(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -27,7 +97,10 @@ func TestCleanText(t *testing.T) {
 
 func TestNewMagazineScraper(t *testing.T) {
 	config := DefaultConfig()
-	scraper := NewMagazineScraper(config)
+	scraper, err := NewMagazineScraper(config)
+	if err != nil {
+		t.Fatalf("NewMagazineScraper() error = %v", err)
+	}
 	if scraper == nil {
 		t.Error("NewMagazineScraper() returned nil")
 	}
@@ -39,8 +112,64 @@ func TestNewMagazineScraper(t *testing.T) {
 	}
 }
 
+func TestMinTLSVersion(t *testing.T) {
+	tests := map[string]uint16{
+		"":    tls.VersionTLS12,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+	for version, want := range tests {
+		config := DefaultConfig()
+		config.MinTLSVersion = version
+		scraper := mustNewScraper(t, config)
+		if got := scraper.transport.TLSClientConfig.MinVersion; got != want {
+			t.Errorf("MinTLSVersion %q: transport MinVersion = %#x, want %#x", version, got, want)
+		}
+	}
+}
+
+func TestInvalidMinTLSVersion(t *testing.T) {
+	config := DefaultConfig()
+	config.MinTLSVersion = "1.4"
+	_, err := NewMagazineScraper(config)
+	if err == nil || !strings.Contains(err.Error(), `invalid TLS version "1.4"`) {
+		t.Errorf("NewMagazineScraper() error = %v, want one naming the invalid version", err)
+	}
+}
+
+func TestBurst(t *testing.T) {
+	for _, burst := range []int{1, 4} {
+		config := DefaultConfig()
+		config.RequestsPerSecond = 0.1
+		config.Burst = burst
+		scraper := mustNewScraper(t, config)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		start := time.Now()
+		for i := 0; i < burst; i++ {
+			if err := scraper.wait(ctx, "https://flipboard.com/@user/mag"); err != nil {
+				t.Fatalf("burst %d: request %d waited: %v", burst, i+1, err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("burst %d: %d requests took %v, want them to proceed immediately", burst, burst, elapsed)
+		}
+		// The next request waits ten seconds for a token, past the deadline
+		if err := scraper.wait(ctx, "https://flipboard.com/@user/mag"); err == nil {
+			t.Errorf("burst %d: request %d proceeded immediately, want it paced", burst, burst+1)
+		}
+		cancel()
+	}
+
+	config := DefaultConfig()
+	config.Burst = -1
+	if _, err := NewMagazineScraper(config); err == nil {
+		t.Error("NewMagazineScraper() accepted a negative burst")
+	}
+}
+
 func TestScrapeURLValidation(t *testing.T) {
-	scraper := NewMagazineScraper(DefaultConfig())
+	scraper := mustNewScraper(t, DefaultConfig())
 	ctx := context.Background()
 	_, err := scraper.ScrapeURL(ctx, "http://invalid-url.com")
 	if err == nil {
@@ -49,7 +178,7 @@ func TestScrapeURLValidation(t *testing.T) {
 }
 
 func TestScrapeURLsValidation(t *testing.T) {
-	scraper := NewMagazineScraper(DefaultConfig())
+	scraper := mustNewScraper(t, DefaultConfig())
 	ctx := context.Background()
 
 	// Test empty URLs
@@ -80,3 +209,1136 @@ func TestDefaultConfig(t *testing.T) {
 		t.Error("Default Timeout should be positive")
 	}
 }
+
+func TestScrapeURLsSelectorsMatchedNothing(t *testing.T) {
+	page := `<html><body><div class="feed"><h3>Not an article</h3></div></body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/empty"})
+	if !errors.Is(err, ErrSelectorsMatchedNothing) {
+		t.Fatalf("Expected ErrSelectorsMatchedNothing, got %v", err)
+	}
+	if len(articles) != 0 {
+		t.Errorf("Expected no articles, got %d", len(articles))
+	}
+}
+
+func TestErrorOnEmpty(t *testing.T) {
+	page := `<html><body><div class="feed"><h3>Not an article</h3></div></body></html>`
+
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/empty"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v, want none by default", err)
+	}
+
+	config := DefaultConfig()
+	config.ErrorOnEmpty = true
+	scraper = newFixtureScraper(t, config, fixtureHandler(page))
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/empty")
+	if !errors.Is(err, ErrNoArticlesFound) {
+		t.Fatalf("ScrapeURL() error = %v, want ErrNoArticlesFound", err)
+	}
+	if len(articles) != 0 {
+		t.Errorf("got %d articles, want none", len(articles))
+	}
+}
+
+func TestScrapeURLsAllFailedIsNotSelectorError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	scraper := newFixtureScraper(t, DefaultConfig(), handler)
+
+	_, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/broken"})
+	if err == nil {
+		t.Fatal("Expected error when every URL fails")
+	}
+	if errors.Is(err, ErrSelectorsMatchedNothing) {
+		t.Error("Failed fetches should not be reported as ErrSelectorsMatchedNothing")
+	}
+}
+
+func TestScrapeURLsDetailed(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "broken") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 100
+	scraper := newFixtureScraper(t, config, handler)
+
+	urls := []string{
+		"https://flipboard.com/@user/broken",
+		"https://flipboard.com/@user/good",
+		"https://flipboard.com/@user/also-broken",
+	}
+	results, err := scraper.ScrapeURLsDetailed(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("ScrapeURLsDetailed() error = %v, want nil while one URL succeeds", err)
+	}
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(results), len(urls))
+	}
+	for i, result := range results {
+		if result.URL != urls[i] {
+			t.Errorf("results[%d].URL = %q, want %q", i, result.URL, urls[i])
+		}
+	}
+	if results[0].Err == nil || results[2].Err == nil {
+		t.Errorf("broken URLs: errors = %v, %v, want both non-nil", results[0].Err, results[2].Err)
+	}
+	if good := results[1]; good.Err != nil || len(good.Articles) != 2 {
+		t.Errorf("good URL: %d articles, error %v, want 2 articles and no error", len(good.Articles), good.Err)
+	}
+
+	articles, err := MergeResults(results, OrderNone)
+	if len(articles) != 2 {
+		t.Errorf("MergeResults() returned %d articles, want 2", len(articles))
+	}
+	if err == nil || !strings.Contains(err.Error(), "@user/broken") || !strings.Contains(err.Error(), "@user/also-broken") {
+		t.Errorf("MergeResults() error = %v, want both failed URLs", err)
+	}
+	if stats := scraper.LastStats(); stats.Failures != 2 || stats.Articles != 2 {
+		t.Errorf("LastStats() = %+v, want 2 failures and 2 articles", stats)
+	}
+
+	// Only when every URL fails is there an aggregate error
+	results, err = scraper.ScrapeURLsDetailed(context.Background(), []string{urls[0], urls[2]})
+	if err == nil {
+		t.Fatal("ScrapeURLsDetailed() error = nil, want an error when every URL fails")
+	}
+	if len(results) != 2 {
+		t.Errorf("got %d results, want 2", len(results))
+	}
+}
+
+func TestMinArticlesPerURL(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Lonely</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	urls := []string{"https://flipboard.com/@user/thin"}
+
+	config := DefaultConfig()
+	config.MinArticlesPerURL = 3
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+	results, err := scraper.ScrapeURLsDetailed(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("ScrapeURLsDetailed() error = %v", err)
+	}
+	thin := results[0]
+	if !errors.Is(thin.Warning, ErrTooFewArticles) || thin.Err != nil || len(thin.Articles) != 1 {
+		t.Errorf("result = %d articles, Err %v, Warning %v, want 1 article and an ErrTooFewArticles warning", len(thin.Articles), thin.Err, thin.Warning)
+	}
+	if stats := scraper.LastStats(); stats.TooFewArticles != 1 || stats.Failures != 0 {
+		t.Errorf("LastStats() = %+v, want 1 URL with too few articles and no failures", stats)
+	}
+
+	config.StrictMinArticles = true
+	scraper = newFixtureScraper(t, config, fixtureHandler(page))
+	results, err = scraper.ScrapeURLsDetailed(context.Background(), urls)
+	if err == nil {
+		t.Error("ScrapeURLsDetailed() error = nil, want a failure under StrictMinArticles")
+	}
+	if thin := results[0]; !errors.Is(thin.Err, ErrTooFewArticles) || thin.Warning != nil {
+		t.Errorf("result Err = %v, Warning = %v, want ErrTooFewArticles as the error", thin.Err, thin.Warning)
+	}
+	if stats := scraper.LastStats(); stats.TooFewArticles != 1 || stats.Failures != 1 {
+		t.Errorf("LastStats() = %+v, want 1 URL with too few articles, counted as a failure", stats)
+	}
+}
+
+func TestScrapeURLsContinueOnError(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+</body></html>`
+	var mu sync.Mutex
+	requested := make(map[string]bool)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		if strings.Contains(r.URL.Path, "broken") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	urls := []string{
+		"https://flipboard.com/@user/broken",
+		"https://flipboard.com/@user/good",
+		"https://flipboard.com/@user/also-good",
+	}
+
+	tests := []struct {
+		name            string
+		continueOnError bool
+		wantArticles    int
+		wantRequested   bool
+	}{
+		{"fail fast", false, 0, false},
+		{"continue on error", true, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(requested)
+			config := DefaultConfig()
+			config.ConcurrentRequests = 1
+			config.RequestsPerSecond = 1000
+			config.ContinueOnError = tt.continueOnError
+			scraper := newFixtureScraper(t, config, handler)
+
+			articles, err := scraper.ScrapeURLs(context.Background(), urls)
+			if err == nil || !strings.Contains(err.Error(), "@user/broken") {
+				t.Errorf("ScrapeURLs() error = %v, want the broken URL's failure", err)
+			}
+			if len(articles) != tt.wantArticles {
+				t.Errorf("got %d articles, want %d", len(articles), tt.wantArticles)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if requested["/@user/also-good"] != tt.wantRequested {
+				t.Errorf("last URL requested = %v, want %v", requested["/@user/also-good"], tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	config := DefaultConfig()
+	config.RequestTimeout = 100 * time.Millisecond
+	scraper := newFixtureScraper(t, config, handler)
+
+	start := time.Now()
+	_, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/slow")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("Expected error for request exceeding RequestTimeout")
+	}
+	if elapsed > time.Second {
+		t.Errorf("Request took %v, expected it to time out near %v", elapsed, config.RequestTimeout)
+	}
+}
+
+func TestExtractMultipleImages(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>Gallery</h3>
+	<a href="https://example.com/gallery">Read</a>
+	<img src="/img/placeholder.gif" data-src="https://cdn.example.com/one.jpg">
+	<img src="https://cdn.example.com/two.jpg">
+	<img src="/img/three.jpg">
+	<img src="https://cdn.example.com/two.jpg">
+</article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/gallery")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1", len(articles))
+	}
+
+	want := []string{
+		"https://cdn.example.com/one.jpg",
+		"https://cdn.example.com/two.jpg",
+		"https://flipboard.com/img/three.jpg",
+	}
+	got := articles[0].Images
+	if len(got) != len(want) {
+		t.Fatalf("Images = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Images[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGenerateArticleID(t *testing.T) {
+	a := Article{Title: "One", URL: "https://example.com/one"}
+	b := Article{Title: "Two", URL: "https://example.com/two"}
+
+	if GenerateArticleID(a) != GenerateArticleID(Article{Title: "Renamed", URL: a.URL}) {
+		t.Error("ID should depend only on the URL")
+	}
+	if GenerateArticleID(a) != GenerateArticleID(a) {
+		t.Error("ID should be stable across calls")
+	}
+	if GenerateArticleID(a) == GenerateArticleID(b) {
+		t.Error("Different URLs should produce different IDs")
+	}
+	if GenerateArticleID(Article{Title: "No link"}) == "" {
+		t.Error("Articles without a URL should still get an ID")
+	}
+}
+
+func TestExtractComments(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>Discussed</h3>
+	<a href="https://example.com/discussed">Read</a>
+	<ul class="comments">
+		<li class="comment">First!</li>
+		<li class="comment">  Great   read </li>
+		<li class="comment"></li>
+		<li class="comment">Disagree</li>
+		<li class="comment">One too many</li>
+	</ul>
+</article>
+</body></html>`
+
+	config := DefaultConfig()
+	config.ExtractComments = true
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/comments")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1", len(articles))
+	}
+
+	want := []string{"First!", "Great read", "Disagree"}
+	got := articles[0].TopComments
+	if len(got) != len(want) {
+		t.Fatalf("TopComments = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("TopComments[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// Disabled by default
+	scraper = newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+	articles, err = scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/comments")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles[0].TopComments) != 0 {
+		t.Errorf("Expected no comments when ExtractComments is off, got %v", articles[0].TopComments)
+	}
+}
+
+func TestMaxArticlesTotalStopsEarly(t *testing.T) {
+	var mu sync.Mutex
+	requested := make(map[string]bool)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = true
+		mu.Unlock()
+		w.Write([]byte(`<html><body>
+<article class="item"><h3>First</h3><a href="` + r.URL.Path + `/1">Read</a></article>
+<article class="item"><h3>Second</h3><a href="` + r.URL.Path + `/2">Read</a></article>
+</body></html>`))
+	})
+
+	config := DefaultConfig()
+	config.ConcurrentRequests = 1
+	config.RequestsPerSecond = 1000
+	config.MaxArticlesTotal = 3
+	scraper := newFixtureScraper(t, config, handler)
+
+	urls := []string{
+		"https://flipboard.com/@user/one",
+		"https://flipboard.com/@user/two",
+		"https://flipboard.com/@user/three",
+		"https://flipboard.com/@user/four",
+	}
+	start := time.Now()
+	articles, err := scraper.ScrapeURLs(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Errorf("got %d articles, want 3", len(articles))
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("preview took %v, expected it to return promptly", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requested["/@user/four"] {
+		t.Error("Expected scraping to stop before the last URL was requested")
+	}
+}
+
+func TestScrapeURLsDetailedArticleLimit(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+<article class="item"><h3>First</h3><a href="` + r.URL.Path + `/1">Read</a></article>
+<article class="item"><h3>Second</h3><a href="` + r.URL.Path + `/2">Read</a></article>
+</body></html>`))
+	})
+	config := DefaultConfig()
+	config.ConcurrentRequests = 1
+	config.RequestsPerSecond = 1000
+	config.MaxArticlesTotal = 3
+	scraper := newFixtureScraper(t, config, handler)
+
+	results, err := scraper.ScrapeURLsDetailed(context.Background(), []string{
+		"https://flipboard.com/@user/one",
+		"https://flipboard.com/@user/two",
+		"https://flipboard.com/@user/three",
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLsDetailed() error = %v", err)
+	}
+	articles, err := MergeResults(results, OrderNone)
+	if err != nil {
+		t.Errorf("MergeResults() error = %v, want URLs stopped by the limit not to count as failures", err)
+	}
+	if len(articles) != 3 {
+		t.Errorf("got %d articles, want 3", len(articles))
+	}
+	if last := results[2]; !errors.Is(last.Err, ErrArticleLimitReached) {
+		t.Errorf("last URL error = %v, want ErrArticleLimitReached", last.Err)
+	}
+}
+
+func TestScrapeURLBlockedByInterstitial(t *testing.T) {
+	page := `<html><body>
+<div class="cookie-consent">
+	<h2>We value your privacy</h2>
+	<button>Accept all</button>
+</div>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	_, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/walled"})
+	if !errors.Is(err, ErrBlockedByInterstitial) {
+		t.Fatalf("Expected ErrBlockedByInterstitial, got %v", err)
+	}
+	if errors.Is(err, ErrSelectorsMatchedNothing) {
+		t.Error("Interstitial should not be reported as a selector failure")
+	}
+}
+
+func TestMaxRedirects(t *testing.T) {
+	var mu sync.Mutex
+	hits := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		n := hits
+		mu.Unlock()
+		// Always redirect somewhere new, forming an endless chain
+		http.Redirect(w, r, fmt.Sprintf("/@user/loop-%d", n), http.StatusFound)
+	})
+
+	config := DefaultConfig()
+	config.MaxRedirects = 3
+	scraper := newFixtureScraper(t, config, handler)
+
+	_, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/loop")
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("Expected ErrTooManyRedirects, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hits != config.MaxRedirects+1 {
+		t.Errorf("server saw %d requests, want %d (original plus %d redirects)", hits, config.MaxRedirects+1, config.MaxRedirects)
+	}
+}
+
+func TestExtractFlipboardID(t *testing.T) {
+	page := `<html><body>
+<article class="item" data-id="flip-123"><h3>One</h3><a href="https://example.com/1?utm=a">Read</a></article>
+<article class="item" data-item-id="flip-456"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+<article class="item"><h3>Three</h3><a href="https://example.com/3">Read</a></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/ids")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	want := []string{"flip-123", "flip-456", ""}
+	if len(articles) != len(want) {
+		t.Fatalf("got %d articles, want %d", len(articles), len(want))
+	}
+	for i := range want {
+		if articles[i].FlipboardID != want[i] {
+			t.Errorf("articles[%d].FlipboardID = %q, want %q", i, articles[i].FlipboardID, want[i])
+		}
+	}
+
+	// The same item under a changed URL is still recognized as seen
+	moved := articles[0]
+	moved.URL = "https://example.com/1?utm=b"
+	moved.ID = GenerateArticleID(moved)
+	if fresh := NewArticlesSince([]Article{moved}, articles); len(fresh) != 0 {
+		t.Errorf("Expected item with known FlipboardID to be deduplicated, got %v", fresh)
+	}
+}
+
+func TestExtractRelatedURLs(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>Linked</h3>
+	<a href="https://example.com/main">Read</a>
+	<div class="related">
+		<a href="https://example.com/related-1">Related one</a>
+		<a href="/section/related-2">Related two</a>
+		<a href="https://example.com/related-1">Related one again</a>
+	</div>
+</article>
+</body></html>`
+	config := DefaultConfig()
+	config.ExtractRelated = true
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/related")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1", len(articles))
+	}
+
+	want := []string{"https://example.com/related-1", "https://flipboard.com/section/related-2"}
+	got := articles[0].RelatedURLs
+	if len(got) != len(want) {
+		t.Fatalf("RelatedURLs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("RelatedURLs[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExtractCurator(t *testing.T) {
+	page := `<html><body>
+<header class="magazine-header">
+	<h1>Code Blog Learning</h1>
+	<a class="curator" href="/@sliperrypenguin">  Slippery Penguin </a>
+</header>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@sliperrypenguin/code")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want 2", len(articles))
+	}
+	for _, article := range articles {
+		if article.Curator != "Slippery Penguin" {
+			t.Errorf("%s: Curator = %q, want %q", article.Title, article.Curator, "Slippery Penguin")
+		}
+	}
+}
+
+func TestExtractMagazineName(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"page title", `<html><head><title>Code Blog Learning | Flipboard</title></head><body>`, "Code Blog Learning"},
+		{"url slug", `<html><head><title>Flipboard</title></head><body>`, "code-blog-learning-6evfsnosy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := tt.page + `<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article></body></html>`
+			scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+			articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@sliperrypenguin/code-blog-learning-6evfsnosy")
+			if err != nil {
+				t.Fatalf("ScrapeURL() error = %v", err)
+			}
+			if len(articles) != 1 {
+				t.Fatalf("got %d articles, want 1", len(articles))
+			}
+			if articles[0].Magazine != tt.want {
+				t.Errorf("Magazine = %q, want %q", articles[0].Magazine, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>Tagged</h3>
+	<a href="https://example.com/1">Read</a>
+	<ul class="topics"><li class="topic">#Go</li><li class="topic"> Programming </li><li class="topic">#go</li></ul>
+	<span class="category">Technology</span>
+</article>
+<article class="item"><h3>Untagged</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/tags")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want 2", len(articles))
+	}
+	if got, want := strings.Join(articles[0].Tags, ","), "Go,Programming,Technology"; got != want {
+		t.Errorf("Tags = %q, want %q", got, want)
+	}
+	if articles[1].Tags != nil {
+		t.Errorf("Tags = %q for an item without tags", articles[1].Tags)
+	}
+
+	path := filepath.Join(t.TempDir(), "tags.csv")
+	if err := NewCSVExporter(path).Export(articles); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if got := readCSVColumn(t, path, "Tags"); got[0] != "Go;Programming;Technology" || got[1] != "" {
+		t.Errorf("CSV Tags column = %q", got)
+	}
+}
+
+func TestExtractPublisherLogo(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<div class="publisher"><img src="/logos/verge.png" alt="The Verge"></div>
+	<h3>With logo</h3>
+	<a href="https://example.com/1">Read</a>
+</article>
+<article class="item">
+	<h3>Without logo</h3>
+	<a href="https://example.com/2">Read</a>
+</article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/logos")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want 2", len(articles))
+	}
+	if got, want := articles[0].PublisherLogoURL, "https://flipboard.com/logos/verge.png"; got != want {
+		t.Errorf("PublisherLogoURL = %q, want %q", got, want)
+	}
+	if got := articles[1].PublisherLogoURL; got != "" {
+		t.Errorf("PublisherLogoURL = %q for an item without a logo", got)
+	}
+}
+
+func TestSelectorFallbackChain(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h2>Fallback title</h2>
+	<a href="https://example.com/fallback">Read</a>
+	<div class="dek">Fallback summary</div>
+</article>
+</body></html>`
+	config := DefaultConfig()
+	config.Selectors = SelectorConfig{
+		Title:   []string{"h3", "h2"},
+		Summary: []string{"p.description", ".dek"},
+	}
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/fallback")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1", len(articles))
+	}
+	if got := articles[0].Title; got != "Fallback title" {
+		t.Errorf("Title = %q, want the h2 fallback", got)
+	}
+	if got := articles[0].Summary; got != "Fallback summary" {
+		t.Errorf("Summary = %q, want the .dek fallback", got)
+	}
+	if got := articles[0].URL; got != "https://example.com/fallback" {
+		t.Errorf("URL = %q, want the default link selector to apply", got)
+	}
+}
+
+func TestCustomSelectors(t *testing.T) {
+	page := `<html><body>
+<div class="card">
+	<h4 class="headline">Custom markup</h4>
+	<a class="permalink" href="https://example.com/custom">Read</a>
+	<img class="logo" src="https://example.com/logo.png">
+	<span class="blurb">Custom summary</span>
+	<span class="writer">By Ada Lovelace</span>
+	<figure><img class="hero" src="https://example.com/hero.jpg"></figure>
+	<span class="published" data-when="2024-03-01">March 1</span>
+	<span class="stamp" datetime="2024-03-02T10:00:00Z">Yesterday</span>
+</div>
+<article class="item"><h3>Default markup</h3><a href="https://example.com/default">Read</a></article>
+</body></html>`
+	config := DefaultConfig()
+	config.Selectors = SelectorConfig{
+		Item:    "div.card",
+		Title:   []string{".headline"},
+		Link:    []string{"a.permalink"},
+		Summary: []string{".blurb"},
+		Author:  []string{".writer"},
+		Image:   []string{"figure img", "img"},
+		Date:    []string{".stamp"},
+	}
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/custom")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want only the custom item", len(articles))
+	}
+	article := articles[0]
+	if article.Title != "Custom markup" || article.URL != "https://example.com/custom" || article.Summary != "Custom summary" {
+		t.Errorf("got title %q, URL %q, summary %q", article.Title, article.URL, article.Summary)
+	}
+	if article.Author != "Ada Lovelace" {
+		t.Errorf("Author = %q, want Ada Lovelace", article.Author)
+	}
+	if len(article.Images) != 1 || article.ImageURL != "https://example.com/hero.jpg" {
+		t.Errorf("Images = %v, want only the figure image", article.Images)
+	}
+	want := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	if !article.Date.Equal(want) || article.DatePrecision != DateExact {
+		t.Errorf("Date = %v (%v), want %v from the datetime attribute", article.Date, article.DatePrecision, want)
+	}
+}
+
+func TestExtractFlippedBy(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>Flipped</h3>
+	<a href="https://example.com/1">Read</a>
+	<div class="flipped-by">Flipped by <span class="name">Ada Lovelace</span></div>
+</article>
+<article class="item">
+	<h3>Label only</h3>
+	<a href="https://example.com/2">Read</a>
+	<div class="flipped-by">Flipped by Grace Hopper</div>
+</article>
+<article class="item">
+	<h3>Not flipped</h3>
+	<a href="https://example.com/3">Read</a>
+</article>
+</body></html>`
+
+	config := DefaultConfig()
+	config.ExtractFlippedBy = true
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/flips")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Fatalf("got %d articles, want 3", len(articles))
+	}
+	for i, want := range []string{"Ada Lovelace", "Grace Hopper", ""} {
+		if got := articles[i].FlippedBy; got != want {
+			t.Errorf("%s: FlippedBy = %q, want %q", articles[i].Title, got, want)
+		}
+	}
+
+	// Extraction is off by default
+	scraper = newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+	articles, err = scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/flips")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if got := articles[0].FlippedBy; got != "" {
+		t.Errorf("FlippedBy = %q with ExtractFlippedBy disabled", got)
+	}
+}
+
+func TestScrapeURLsNoDuplicateArticles(t *testing.T) {
+	// Each magazine page lists its own two articles
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		fmt.Fprintf(w, `<html><body>
+<article class="item"><h3>%[1]s first</h3><a href="https://example.com/%[1]s/1">Read</a></article>
+<article class="item"><h3>%[1]s second</h3><a href="https://example.com/%[1]s/2">Read</a></article>
+</body></html>`, name)
+	})
+
+	for _, concurrent := range []int{1, 2} {
+		config := DefaultConfig()
+		config.ConcurrentRequests = concurrent
+		config.RequestsPerSecond = 100
+		scraper := newFixtureScraper(t, config, handler)
+
+		// Scrape twice so callbacks from the first run would show up too
+		for run := 1; run <= 2; run++ {
+			articles, err := scraper.ScrapeURLs(context.Background(), []string{
+				"https://flipboard.com/@user/alpha",
+				"https://flipboard.com/@user/beta",
+			})
+			if err != nil {
+				t.Fatalf("concurrency %d, run %d: ScrapeURLs() error = %v", concurrent, run, err)
+			}
+			if len(articles) != 4 {
+				t.Errorf("concurrency %d, run %d: got %d articles, want 4", concurrent, run, len(articles))
+			}
+			seen := make(map[string]bool)
+			for _, article := range articles {
+				if seen[article.URL] {
+					t.Errorf("concurrency %d, run %d: %s appears more than once", concurrent, run, article.URL)
+				}
+				seen[article.URL] = true
+			}
+		}
+	}
+}
+
+func TestExtractAuthor(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>With byline</h3>
+	<a href="https://example.com/1">Read</a>
+	<span class="author">By  Jane Doe</span>
+</article>
+<article class="item">
+	<h3>Rel author</h3>
+	<a href="https://example.com/2">Read</a>
+	<a rel="author" href="/@john">John Smith</a>
+</article>
+<article class="item">
+	<h3>No byline</h3>
+	<a href="https://example.com/3">Read</a>
+</article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/authors")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Fatalf("got %d articles, want 3", len(articles))
+	}
+	for i, want := range []string{"Jane Doe", "John Smith", ""} {
+		if got := articles[i].Author; got != want {
+			t.Errorf("%s: Author = %q, want %q", articles[i].Title, got, want)
+		}
+	}
+}
+
+func TestExtractImageURL(t *testing.T) {
+	page := `<html><body>
+<article class="item">
+	<h3>Lazy</h3>
+	<a href="https://example.com/1">Read</a>
+	<img src="/placeholder.gif" data-src="https://cdn.example.com/lazy.jpg">
+</article>
+<article class="item">
+	<div class="publisher"><img src="/logos/pub.png"></div>
+	<h3>Plain</h3>
+	<a href="https://example.com/2">Read</a>
+	<img src="/images/plain.jpg">
+</article>
+<article class="item">
+	<h3>No image</h3>
+	<a href="https://example.com/3">Read</a>
+</article>
+</body></html>`
+	scraper := newFixtureScraper(t, DefaultConfig(), fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/images")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Fatalf("got %d articles, want 3", len(articles))
+	}
+	want := []string{"https://cdn.example.com/lazy.jpg", "https://flipboard.com/images/plain.jpg", ""}
+	for i := range want {
+		if got := articles[i].ImageURL; got != want[i] {
+			t.Errorf("%s: ImageURL = %q, want %q", articles[i].Title, got, want[i])
+		}
+	}
+}
+
+func TestUserAgentRotation(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, r.UserAgent())
+		mu.Unlock()
+		w.Write([]byte(`<html><body><article class="item"><h3>Title</h3><a href="https://example.com/1">Read</a></article></body></html>`))
+	})
+
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.UserAgents = []string{"agent-a", "agent-b", "agent-c"}
+	scraper := newFixtureScraper(t, config, handler)
+	for i := 0; i < 4; i++ {
+		if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag"); err != nil {
+			t.Fatalf("ScrapeURL() error = %v", err)
+		}
+	}
+
+	// Without UserAgents every request sends the default
+	defaults := newFixtureScraper(t, DefaultConfig(), handler)
+	if _, err := defaults.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"agent-a", "agent-b", "agent-c", "agent-a", defaultUserAgent}
+	if strings.Join(sent, "|") != strings.Join(want, "|") {
+		t.Errorf("User-Agents sent = %q, want %q", sent, want)
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]http.Header)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.Host] = r.Header.Clone()
+		mu.Unlock()
+		w.Write([]byte(`<html><head><meta property="og:description" content="From the page"></head><body>
+<article class="item"><h3>Title</h3><a href="https://example.com/1">Read</a></article></body></html>`))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.FetchOpenGraph = true
+	config.Headers = map[string]string{"Authorization": "Bearer secret", "Referer": "https://flipboard.com/"}
+	scraper := newFixtureScraper(t, config, handler)
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/gated"); err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	magazine := received["flipboard.com"]
+	if magazine.Get("Authorization") != "Bearer secret" || magazine.Get("Referer") != "https://flipboard.com/" {
+		t.Errorf("magazine request headers = %v, want the custom headers", magazine)
+	}
+	if magazine.Get("User-Agent") != defaultUserAgent {
+		t.Errorf("User-Agent = %q, want the default kept", magazine.Get("User-Agent"))
+	}
+	if article, ok := received["example.com"]; !ok {
+		t.Error("the article page was not fetched for Open Graph tags")
+	} else if article.Get("Authorization") != "" {
+		t.Error("custom headers were sent to the article's site")
+	}
+}
+
+func TestRespectRobotsTxt(t *testing.T) {
+	var magazineRequests sync.Map
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /@user/private\n"))
+			return
+		}
+		magazineRequests.Store(r.URL.Path, true)
+		w.Write([]byte(`<html><body><article class="item"><h3>Title</h3><a href="https://example.com/1">Read</a></article></body></html>`))
+	})
+
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.RespectRobotsTxt = true
+	scraper := newFixtureScraper(t, config, handler)
+
+	_, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/private")
+	if !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("ScrapeURL(disallowed) error = %v, want ErrDisallowedByRobots", err)
+	}
+	if _, requested := magazineRequests.Load("/@user/private"); requested {
+		t.Error("requested a path robots.txt disallows")
+	}
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/public"); err != nil {
+		t.Errorf("ScrapeURL(allowed) error = %v", err)
+	}
+
+	// robots.txt is ignored by default
+	ignoring := newFixtureScraper(t, DefaultConfig(), handler)
+	if _, err := ignoring.ScrapeURL(context.Background(), "https://flipboard.com/@user/private"); err != nil {
+		t.Errorf("ScrapeURL() without RespectRobotsTxt error = %v", err)
+	}
+}
+
+func TestRelativeArticleURLsResolved(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>Relative</h3><a href="/article/123">Read</a></article>
+<article class="item"><h3>Protocol relative</h3><a href="//cdn.example.com/story">Read</a></article>
+<article class="item"><h3>Absolute</h3><a href="https://example.com/a?x=1">Read</a></article>
+<article class="item"><h3>No link</h3></article>
+</body></html>`
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag")
+	if err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+	want := []string{
+		"https://flipboard.com/article/123",
+		"https://cdn.example.com/story",
+		"https://example.com/a?x=1",
+		"",
+	}
+	if len(articles) != len(want) {
+		t.Fatalf("got %d articles, want %d", len(articles), len(want))
+	}
+	for i, article := range articles {
+		if article.URL != want[i] {
+			t.Errorf("%s: URL = %q, want %q", article.Title, article.URL, want[i])
+		}
+	}
+	if articles[0].ID != GenerateArticleID(Article{URL: want[0]}) {
+		t.Error("ID wasn't derived from the resolved URL")
+	}
+}
+
+func TestMaxPagesFollowsNextLinks(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		mu.Lock()
+		requests = append(requests, page)
+		mu.Unlock()
+		next := map[string]string{"1": "2", "2": "3", "3": "4", "4": "5"}[page]
+		fmt.Fprintf(w, `<html><body>
+<article class="item"><h3>Page %s</h3><a href="https://example.com/%s">Read</a></article>
+<a rel="next" href="/@user/mag?page=%s">More</a>
+</body></html>`, page, page, next)
+	})
+
+	tests := []struct {
+		name     string
+		maxPages int
+		want     []string
+	}{
+		{"first page only", 0, []string{"Page 1"}},
+		{"three pages", 3, []string{"Page 1", "Page 2", "Page 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			requests = nil
+			mu.Unlock()
+			config := DefaultConfig()
+			config.RequestsPerSecond = 1000
+			config.MaxPages = tt.maxPages
+			scraper := newFixtureScraper(t, config, handler)
+
+			articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/mag")
+			if err != nil {
+				t.Fatalf("ScrapeURL() error = %v", err)
+			}
+			var titles []string
+			for _, article := range articles {
+				titles = append(titles, article.Title)
+				if article.SourceMagazineURL != "https://flipboard.com/@user/mag" {
+					t.Errorf("%s: SourceMagazineURL = %q, want the magazine", article.Title, article.SourceMagazineURL)
+				}
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got articles %v, want %v", titles, tt.want)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(requests) != len(tt.want) {
+				t.Errorf("fetched pages %v, want %d", requests, len(tt.want))
+			}
+		})
+	}
+}
+
+// countingLimiter is a RateLimiter that never waits but counts its calls
+type countingLimiter struct{ waits atomic.Int32 }
+
+func (l *countingLimiter) Wait(context.Context) error {
+	l.waits.Add(1)
+	return nil
+}
+
+func TestMaxPagesWaitsForRateLimiter(t *testing.T) {
+	// Three pages, the last linking back to the first
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		next := map[string]string{"": "?page=2", "2": "?page=3", "3": ""}[page]
+		fmt.Fprintf(w, `<html><body>
+<article class="item"><h3>Page %s</h3><a href="https://example.com/%s">Read</a></article>
+<link rel="next" href="/@user/mag%s">
+</body></html>`, page, page, next)
+	})
+	limiter := &countingLimiter{}
+	config := DefaultConfig()
+	config.MaxPages = 10
+	config.RateLimiter = limiter
+	scraper := newFixtureScraper(t, config, handler)
+
+	articles, err := scraper.ScrapeURLs(context.Background(), []string{"https://flipboard.com/@user/mag"})
+	if err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+	if len(articles) != 3 {
+		t.Errorf("got %d articles, want one per page without revisiting the first", len(articles))
+	}
+	if n := limiter.waits.Load(); n != 3 {
+		t.Errorf("rate limiter waited %d times, want once per page", n)
+	}
+}
+
+func TestScrapeURLReturnsPartialArticlesOnCancel(t *testing.T) {
+	page := `<html><body>
+<article class="item"><h3>First</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Second</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	var seen atomic.Int32
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.Transform = func(article Article) Article {
+		if seen.Add(1) == 1 {
+			// Cancel once the first article is parsed, and hold the
+			// second until the test is over
+			cancel()
+		} else {
+			<-release
+		}
+		return article
+	}
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	articles, err := scraper.ScrapeURL(ctx, "https://flipboard.com/@user/mag")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ScrapeURL() error = %v, want the cancellation", err)
+	}
+	if len(articles) != 1 || articles[0].Title != "First" {
+		t.Errorf("got articles %+v, want the first one parsed before cancelling", articles)
+	}
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// slowStartGate bounds the number of in-flight requests with a limit that
+// starts at 1 and doubles after every successful request until it reaches
+// max. Once the ramp duration has elapsed the full limit applies regardless.
+type slowStartGate struct {
+	mu      sync.Mutex
+	limit   int
+	max     int
+	active  int
+	start   time.Time
+	ramp    time.Duration
+	changed chan struct{} // closed and replaced whenever a slot may have opened
+}
+
+// newSlowStartGate creates a gate ramping up to max concurrent requests
+func newSlowStartGate(max int, ramp time.Duration) *slowStartGate {
+	if max < 1 {
+		max = 1
+	}
+	return &slowStartGate{
+		limit:   1,
+		max:     max,
+		start:   time.Now(),
+		ramp:    ramp,
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire blocks until a slot is available or the context is done
+func (g *slowStartGate) acquire(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		if g.ramp > 0 && time.Since(g.start) >= g.ramp {
+			g.limit = g.max
+		}
+		if g.active < g.limit {
+			g.active++
+			g.mu.Unlock()
+			return nil
+		}
+		changed := g.changed
+		g.mu.Unlock()
+
+		var rampDone <-chan time.Time
+		var timer *time.Timer
+		if g.ramp > 0 {
+			timer = time.NewTimer(g.ramp - time.Since(g.start))
+			rampDone = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			if timer != nil {
+				timer.Stop()
+			}
+			return err
+		case <-changed:
+		case <-rampDone:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// release frees a slot, doubling the limit if the request succeeded
+func (g *slowStartGate) release(success bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.active--
+	if success && g.limit < g.max {
+		g.limit *= 2
+		if g.limit > g.max {
+			g.limit = g.max
+		}
+	}
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
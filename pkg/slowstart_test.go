@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlowStartGate(t *testing.T) {
+	type span struct{ start, end time.Time }
+	var (
+		mu       sync.Mutex
+		spans    []span
+		inFlight int
+		peak     int
+		wg       sync.WaitGroup
+	)
+
+	gate := newSlowStartGate(4, 0)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := gate.acquire(context.Background()); err != nil {
+				t.Errorf("acquire() error = %v", err)
+				return
+			}
+
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			start := time.Now()
+			mu.Unlock()
+
+			time.Sleep(30 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			spans = append(spans, span{start, time.Now()})
+			mu.Unlock()
+			gate.release(true)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	if spans[1].start.Before(spans[0].end) {
+		t.Error("Second request started before the first finished; expected serialized start")
+	}
+	if peak < 2 || peak > 4 {
+		t.Errorf("Peak concurrency = %d, want between 2 and 4", peak)
+	}
+}
+
+func TestSlowStartGateRampDuration(t *testing.T) {
+	gate := newSlowStartGate(3, 20*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Nothing succeeds, so only the ramp deadline can widen the limit
+	for i := 0; i < 3; i++ {
+		if err := gate.acquire(ctx); err != nil {
+			t.Fatalf("acquire() %d error = %v", i, err)
+		}
+	}
+}
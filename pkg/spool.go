@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"sync"
+)
+
+// ArticleSpool collects articles in memory until it holds threshold of
+// them, then spills them to a temporary NDJSON file and starts over, so a
+// very large scrape keeps at most threshold articles in memory. Articles
+// are read back in the order they were added. Close removes the file.
+type ArticleSpool struct {
+	mu        sync.Mutex
+	threshold int
+	dir       string
+	memory    []Article
+	file      *os.File
+	writer    *bufio.Writer
+	spilled   int
+}
+
+// NewArticleSpool creates a spool holding up to threshold articles in
+// memory, spilling to a temporary file in dir (os.TempDir when empty).
+// A threshold below 1 never spills.
+func NewArticleSpool(threshold int, dir string) *ArticleSpool {
+	return &ArticleSpool{threshold: threshold, dir: dir}
+}
+
+// Add appends articles, spilling to disk whenever the in-memory threshold
+// is reached
+func (s *ArticleSpool) Add(articles ...Article) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, article := range articles {
+		s.memory = append(s.memory, article)
+		if s.threshold > 0 && len(s.memory) >= s.threshold {
+			if err := s.spill(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// spill moves the in-memory articles to the end of the spill file
+func (s *ArticleSpool) spill() error {
+	if s.file == nil {
+		file, err := os.CreateTemp(s.dir, "flipboard-spool-*.ndjson")
+		if err != nil {
+			return fmt.Errorf("failed to create spill file: %w", err)
+		}
+		s.file = file
+		s.writer = bufio.NewWriter(file)
+	}
+
+	encoder := json.NewEncoder(s.writer)
+	for _, article := range s.memory {
+		if err := encoder.Encode(article); err != nil {
+			return fmt.Errorf("failed to spill article: %w", err)
+		}
+	}
+	s.spilled += len(s.memory)
+	s.memory = s.memory[:0]
+	return nil
+}
+
+// Len returns how many articles the spool holds, in memory and on disk
+func (s *ArticleSpool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spilled + len(s.memory)
+}
+
+// Spilled returns how many articles have been written to disk
+func (s *ArticleSpool) Spilled() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spilled
+}
+
+// All yields every article in the order added, reading spilled articles
+// back from disk one at a time. A read error is yielded once and ends the
+// sequence. The spool must not be added to while it is being read.
+func (s *ArticleSpool) All() iter.Seq2[Article, error] {
+	return func(yield func(Article, error) bool) {
+		s.mu.Lock()
+		file, memory := s.file, s.memory
+		var err error
+		if s.writer != nil {
+			err = s.writer.Flush()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			yield(Article{}, fmt.Errorf("failed to flush spill file: %w", err))
+			return
+		}
+
+		if file != nil {
+			spilled, err := os.Open(file.Name())
+			if err != nil {
+				yield(Article{}, fmt.Errorf("failed to open spill file: %w", err))
+				return
+			}
+			defer spilled.Close()
+
+			decoder := json.NewDecoder(bufio.NewReader(spilled))
+			for {
+				var article Article
+				err := decoder.Decode(&article)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					yield(Article{}, fmt.Errorf("failed to read spill file: %w", err))
+					return
+				}
+				if !yield(article, nil) {
+					return
+				}
+			}
+		}
+
+		for _, article := range memory {
+			if !yield(article, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close removes the spill file, if one was created
+func (s *ArticleSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file, s.writer = nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to remove spill file: %w", err)
+	}
+	return nil
+}
+
+// StreamExporter is implemented by exporters that can write articles as
+// they are read, without holding them all in memory
+type StreamExporter interface {
+	Exporter
+	ExportSeq(articles iter.Seq2[Article, error]) error
+}
+
+// articleSeq yields articles as a sequence without errors
+func articleSeq(articles []Article) iter.Seq2[Article, error] {
+	return func(yield func(Article, error) bool) {
+		for _, article := range articles {
+			if !yield(article, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ExportSpool writes the spool's articles with exporter, streaming them
+// when it is a StreamExporter (csv and ndjson). Other exporters need every
+// article in memory at once, so the spool is read back in full first.
+func ExportSpool(exporter Exporter, spool *ArticleSpool) error {
+	if stream, ok := exporter.(StreamExporter); ok {
+		return stream.ExportSeq(spool.All())
+	}
+
+	articles := make([]Article, 0, spool.Len())
+	for article, err := range spool.All() {
+		if err != nil {
+			return err
+		}
+		articles = append(articles, article)
+	}
+	return exporter.Export(articles)
+}
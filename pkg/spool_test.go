@@ -0,0 +1,158 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestArticleSpool(t *testing.T) {
+	dir := t.TempDir()
+	spool := NewArticleSpool(3, dir)
+	for i := 0; i < 10; i++ {
+		if err := spool.Add(Article{Title: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	if spool.Len() != 10 || spool.Spilled() != 9 {
+		t.Errorf("Len() = %d, Spilled() = %d, want 10 and 9", spool.Len(), spool.Spilled())
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "flipboard-spool-*"))
+	if len(files) != 1 {
+		t.Fatalf("got spill files %v, want one", files)
+	}
+
+	var titles []string
+	for article, err := range spool.All() {
+		if err != nil {
+			t.Fatalf("All() error = %v", err)
+		}
+		titles = append(titles, article.Title)
+	}
+	if fmt.Sprint(titles) != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Errorf("All() = %v, want every article in order", titles)
+	}
+
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("spill file still exists after Close: %v", err)
+	}
+}
+
+func TestArticleSpoolBelowThreshold(t *testing.T) {
+	dir := t.TempDir()
+	spool := NewArticleSpool(5, dir)
+	defer spool.Close()
+	spool.Add(Article{Title: "a"}, Article{Title: "b"})
+
+	if spool.Spilled() != 0 {
+		t.Errorf("Spilled() = %d, want 0", spool.Spilled())
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("got spill files %v below the threshold", files)
+	}
+}
+
+func TestScrapeURLsSpooled(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := "<html><body>"
+		for i := 0; i < 5; i++ {
+			page += fmt.Sprintf(`<article class="item"><h3>%s %d</h3><a href="https://example.com%s/%d">Read</a></article>`, r.URL.Path, i, r.URL.Path, i)
+		}
+		w.Write([]byte(page + "</body></html>"))
+	})
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.SpillThreshold = 2
+	config.SpillDir = t.TempDir()
+	scraper := newFixtureScraper(t, config, handler)
+
+	spool, err := scraper.ScrapeURLsSpooled(context.Background(), []string{
+		"https://flipboard.com/@user/one",
+		"https://flipboard.com/@user/two",
+		"https://flipboard.com/@user/three",
+	})
+	if err != nil {
+		t.Fatalf("ScrapeURLsSpooled() error = %v", err)
+	}
+	defer spool.Close()
+	if spool.Len() != 15 {
+		t.Errorf("Len() = %d, want 15", spool.Len())
+	}
+	if spool.Spilled() == 0 {
+		t.Error("nothing was spilled to disk with a threshold of 2")
+	}
+
+	// Streaming exporters read the spool back one article at a time
+	output := filepath.Join(t.TempDir(), "out")
+	if err := ExportSpool(NewCSVExporter(output+".csv"), spool); err != nil {
+		t.Fatalf("ExportSpool(csv) error = %v", err)
+	}
+	titles := readCSVColumn(t, output+".csv", "Title")
+	if len(titles) != 15 {
+		t.Fatalf("CSV export has %d articles, want 15", len(titles))
+	}
+	sort.Strings(titles)
+	if titles[0] != "/@user/one 0" || titles[14] != "/@user/two 4" {
+		t.Errorf("CSV export titles = %v", titles)
+	}
+
+	// Other exporters get every article at once
+	if err := ExportSpool(NewJSONExporter(output+".json"), spool); err != nil {
+		t.Fatalf("ExportSpool(json) error = %v", err)
+	}
+	data, err := os.ReadFile(output + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []Article
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 15 {
+		t.Errorf("JSON export has %d articles, want 15", len(exported))
+	}
+}
+
+func TestSpooledScrapeRejectsDedup(t *testing.T) {
+	config := DefaultConfig()
+	config.SpillThreshold = 2
+	config.Dedup = true
+	if _, err := NewMagazineScraper(config); !errors.Is(err, errSpoolDedup) {
+		t.Errorf("NewMagazineScraper() error = %v, want SpillThreshold with Dedup rejected", err)
+	}
+
+	// Without a threshold nothing spills, but articles still aren't deduped
+	config.SpillThreshold = 0
+	spool, err := mustNewScraper(t, config).ScrapeURLsSpooled(context.Background(), []string{"https://flipboard.com/@user/one"})
+	defer spool.Close()
+	if !errors.Is(err, errSpoolDedup) {
+		t.Errorf("ScrapeURLsSpooled() error = %v, want Dedup rejected", err)
+	}
+}
+
+func TestSpillThresholdNeedsSpooledScrape(t *testing.T) {
+	config := DefaultConfig()
+	config.SpillThreshold = 2
+	scraper := mustNewScraper(t, config)
+	urls := []string{"https://flipboard.com/@user/one"}
+
+	if _, err := scraper.ScrapeURL(context.Background(), urls[0]); !errors.Is(err, errSpillNeedsSpool) {
+		t.Errorf("ScrapeURL() error = %v, want SpillThreshold rejected", err)
+	}
+	if _, err := scraper.ScrapeURLs(context.Background(), urls); !errors.Is(err, errSpillNeedsSpool) {
+		t.Errorf("ScrapeURLs() error = %v, want SpillThreshold rejected", err)
+	}
+	if _, err := scraper.ScrapeURLsDetailed(context.Background(), urls); !errors.Is(err, errSpillNeedsSpool) {
+		t.Errorf("ScrapeURLsDetailed() error = %v, want SpillThreshold rejected", err)
+	}
+}
@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScrapeStats summarizes a single ScrapeURLs run
+type ScrapeStats struct {
+	Started  time.Time
+	URLs     int
+	Articles int
+	Failures int
+	Duration time.Duration
+	// Unchanged counts magazines skipped because ScraperConfig.Updates
+	// showed no update since the last run. It isn't written by AppendStats.
+	Unchanged int
+	// TooFewArticles counts URLs that yielded fewer than
+	// ScraperConfig.MinArticlesPerURL articles. It isn't written by
+	// AppendStats.
+	TooFewArticles int
+}
+
+// statsHeader names the columns of a stats CSV file
+var statsHeader = []string{"Timestamp", "URLs", "Articles", "Failures", "Duration Seconds"}
+
+// AppendStats adds stats as one row to the metrics file at path, creating
+// it if needed, so repeated runs build up a history of scrape health. The
+// format is chosen from the file extension: .csv or .db (SQLite).
+func AppendStats(path string, stats ScrapeStats) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return appendCSVStats(path, stats)
+	case ".db", ".sqlite", ".sqlite3":
+		return appendSQLiteStats(path, stats)
+	default:
+		return fmt.Errorf("unsupported stats file format: %s", path)
+	}
+}
+
+// appendCSVStats appends a row, writing the header first for a new file
+func appendCSVStats(path string, stats ScrapeStats) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open stats file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat stats file: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+	if info.Size() == 0 {
+		if err := writer.Write(statsHeader); err != nil {
+			return fmt.Errorf("failed to write stats header: %w", err)
+		}
+	}
+	err = writer.Write([]string{
+		stats.Started.UTC().Format(time.RFC3339),
+		strconv.Itoa(stats.URLs),
+		strconv.Itoa(stats.Articles),
+		strconv.Itoa(stats.Failures),
+		strconv.FormatFloat(stats.Duration.Seconds(), 'f', 3, 64),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write stats row: %w", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	return nil
+}
+
+// appendSQLiteStats inserts a row into the scrape_stats table
+func appendSQLiteStats(path string, stats ScrapeStats) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS scrape_stats (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			started_at DATETIME NOT NULL,
+			urls INTEGER,
+			articles INTEGER,
+			failures INTEGER,
+			duration_seconds REAL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create scrape_stats table: %w", err)
+	}
+
+	_, err = db.Exec(
+		"INSERT INTO scrape_stats (started_at, urls, articles, failures, duration_seconds) VALUES (?, ?, ?, ?, ?)",
+		stats.Started.UTC(), stats.URLs, stats.Articles, stats.Failures, stats.Duration.Seconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert stats: %w", err)
+	}
+	return nil
+}
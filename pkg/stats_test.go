@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runTwice scrapes one good and one failing magazine, then only the good
+// one, appending each run's stats to path
+func runTwice(t *testing.T, path string) {
+	t.Helper()
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "broken") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	config := DefaultConfig()
+	config.ConcurrentRequests = 1
+	config.RequestsPerSecond = 100
+	scraper := newFixtureScraper(t, config, handler)
+
+	runs := [][]string{
+		{"https://flipboard.com/@user/good", "https://flipboard.com/@user/broken"},
+		{"https://flipboard.com/@user/good"},
+	}
+	for _, urls := range runs {
+		scraper.ScrapeURLs(context.Background(), urls)
+		if err := AppendStats(path, scraper.LastStats()); err != nil {
+			t.Fatalf("AppendStats() error = %v", err)
+		}
+	}
+}
+
+func TestAppendStatsCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.csv")
+	runTwice(t, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read stats CSV: %v", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("stats CSV has %d rows, want header and 2 runs", len(rows))
+	}
+	// URLs, Articles, Failures
+	want := [][]string{{"2", "2", "1"}, {"1", "2", "0"}}
+	for i, row := range rows[1:] {
+		if got := row[1:4]; strings.Join(got, ",") != strings.Join(want[i], ",") {
+			t.Errorf("run %d: URLs, Articles, Failures = %v, want %v", i+1, got, want[i])
+		}
+		if row[0] == "" || row[4] == "" {
+			t.Errorf("run %d: missing timestamp or duration: %v", i+1, row)
+		}
+	}
+}
+
+func TestAppendStatsSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	runTwice(t, path)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT urls, articles, failures, duration_seconds FROM scrape_stats ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query stats: %v", err)
+	}
+	defer rows.Close()
+
+	want := [][3]int{{2, 2, 1}, {1, 2, 0}}
+	var i int
+	for ; rows.Next(); i++ {
+		var got [3]int
+		var duration float64
+		if err := rows.Scan(&got[0], &got[1], &got[2], &duration); err != nil {
+			t.Fatal(err)
+		}
+		if i < len(want) && got != want[i] {
+			t.Errorf("run %d: URLs, Articles, Failures = %v, want %v", i+1, got, want[i])
+		}
+		if duration <= 0 {
+			t.Errorf("run %d: duration = %v, want > 0", i+1, duration)
+		}
+	}
+	if i != 2 {
+		t.Errorf("scrape_stats has %d rows, want 2", i)
+	}
+}
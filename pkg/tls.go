@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// defaultMinTLSVersion is used when ScraperConfig.MinTLSVersion is empty
+const defaultMinTLSVersion = tls.VersionTLS12
+
+// tlsVersions maps the accepted MinTLSVersion spellings to crypto/tls
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion converts a TLS version such as "1.2" or "1.3" into its
+// crypto/tls constant. An empty version means TLS 1.2.
+func ParseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return defaultMinTLSVersion, nil
+	}
+	if v, ok := tlsVersions[version]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("invalid TLS version %q: want 1.0, 1.1, 1.2 or 1.3", version)
+}
+
+// newTransport returns a copy of http.DefaultTransport that refuses to
+// connect over TLS older than minVersion
+func newTransport(minVersion uint16) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: minVersion}
+	return transport
+}
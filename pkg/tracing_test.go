@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttribute(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestScrapeURLTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	page := `<html><body>
+<article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article>
+<article class="item"><h3>Two</h3><a href="https://example.com/2">Read</a></article>
+</body></html>`
+	config := DefaultConfig()
+	config.TracerProvider = provider
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	url := "https://flipboard.com/@user/traced"
+	if _, err := scraper.ScrapeURL(context.Background(), url); err != nil {
+		t.Fatalf("ScrapeURL() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "flipboard.scrape" {
+		t.Errorf("span name = %q, want flipboard.scrape", span.Name)
+	}
+	if v, ok := spanAttribute(span, "url.full"); !ok || v.AsString() != url {
+		t.Errorf("url.full = %v, want %q", v.AsString(), url)
+	}
+	if v, ok := spanAttribute(span, "http.response.status_code"); !ok || v.AsInt64() != http.StatusOK {
+		t.Errorf("http.response.status_code = %v, want 200", v.AsInt64())
+	}
+	if v, ok := spanAttribute(span, "flipboard.article_count"); !ok || v.AsInt64() != 2 {
+		t.Errorf("flipboard.article_count = %v, want 2", v.AsInt64())
+	}
+}
+
+func TestScrapeURLTracingRecordsErrors(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	config := DefaultConfig()
+	config.TracerProvider = provider
+	scraper := newFixtureScraper(t, config, handler)
+
+	if _, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/missing"); err == nil {
+		t.Fatal("Expected error for 404 response")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("span status = %v, want Error", spans[0].Status.Code)
+	}
+	if v, _ := spanAttribute(spans[0], "http.response.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("http.response.status_code = %v, want 404", v.AsInt64())
+	}
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// updatedSelectors locate a magazine's last-updated time in its header or
+// page metadata, most specific first, with the attribute holding the time
+var updatedSelectors = []struct{ selector, attr string }{
+	{".magazine-header time[datetime]", "datetime"},
+	{"header time[datetime]", "datetime"},
+	{`meta[property="og:updated_time"]`, "content"},
+	{`meta[property="article:modified_time"]`, "content"},
+}
+
+// UpdateTracker remembers each magazine's last-updated time between runs,
+// in a JSON file, so magazines that haven't changed can be skipped. Times
+// seen while scraping are staged until Save, so a run whose export fails
+// doesn't mark its magazines as seen.
+type UpdateTracker struct {
+	path string
+
+	mu      sync.Mutex
+	updated map[string]time.Time
+	// pending holds the times recorded since the last Save or Discard
+	pending map[string]time.Time
+}
+
+// LoadUpdateTracker reads the last-updated times saved at path. A missing
+// file gives an empty tracker that Save will create.
+func LoadUpdateTracker(path string) (*UpdateTracker, error) {
+	t := &UpdateTracker{
+		path:    path,
+		updated: make(map[string]time.Time),
+		pending: make(map[string]time.Time),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read update times: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.updated); err != nil {
+		return nil, fmt.Errorf("failed to parse update times: %w", err)
+	}
+	return t, nil
+}
+
+// unchanged reports whether magazineURL was last saved as updated at
+// updated or later
+func (t *UpdateTracker) unchanged(magazineURL string, updated time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	last, ok := t.updated[magazineURL]
+	return ok && !updated.After(last)
+}
+
+// record stages the last-updated time seen for magazineURL until Save
+func (t *UpdateTracker) record(magazineURL string, updated time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[magazineURL] = updated
+}
+
+// Save commits the times recorded since the last Save and writes them to
+// the tracker's file. Call it once the scraped articles have been exported,
+// so a failed export doesn't make the next run skip magazines whose
+// articles were never saved.
+func (t *UpdateTracker) Save() error {
+	t.mu.Lock()
+	for magazineURL, updated := range t.pending {
+		t.updated[magazineURL] = updated
+	}
+	clear(t.pending)
+	data, err := json.MarshalIndent(t.updated, "", "  ")
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save update times: %w", err)
+	}
+	return nil
+}
+
+// Discard drops the times recorded since the last Save, e.g. those of a
+// run whose export failed, so a tracker reused across runs doesn't commit
+// them with a later run's Save
+func (t *UpdateTracker) Discard() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clear(t.pending)
+}
+
+// extractUpdated returns the magazine's last-updated time, or the zero time
+// when the page doesn't show one
+func extractUpdated(e *colly.HTMLElement) time.Time {
+	for _, candidate := range updatedSelectors {
+		value, ok := e.DOM.Find(candidate.selector).First().Attr(candidate.attr)
+		if !ok {
+			continue
+		}
+		if updated, err := time.Parse(time.RFC3339, value); err == nil {
+			return updated
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUpdateTrackerSkipsUnchangedMagazine(t *testing.T) {
+	var updated atomic.Value
+	updated.Store("2024-05-01T08:00:00Z")
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+<header class="magazine-header"><time datetime="%s">Updated</time></header>
+<article class="item"><h3>Story</h3><a href="https://example.com/story">Read</a></article>
+</body></html>`, updated.Load())
+	})
+
+	path := filepath.Join(t.TempDir(), "updates.json")
+	tracker, err := LoadUpdateTracker(path)
+	if err != nil {
+		t.Fatalf("LoadUpdateTracker() error = %v", err)
+	}
+	config := DefaultConfig()
+	config.Updates = tracker
+	scraper := newFixtureScraper(t, config, handler)
+	urls := []string{"https://flipboard.com/@user/tech"}
+
+	// The first run records the update time
+	articles, err := scraper.ScrapeURLs(context.Background(), urls)
+	if err != nil || len(articles) != 1 {
+		t.Fatalf("first run: got %d articles, error = %v", len(articles), err)
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A fresh tracker loaded from disk sees the magazine as unchanged
+	if tracker, err = LoadUpdateTracker(path); err != nil {
+		t.Fatalf("LoadUpdateTracker() error = %v", err)
+	}
+	config.Updates = tracker
+	scraper = newFixtureScraper(t, config, handler)
+	articles, err = scraper.ScrapeURLs(context.Background(), urls)
+	if err != nil || len(articles) != 0 {
+		t.Fatalf("unchanged run: got %d articles, error = %v; want the scrape skipped", len(articles), err)
+	}
+	if stats := scraper.LastStats(); stats.Unchanged != 1 || stats.Failures != 0 {
+		t.Errorf("LastStats() = %+v, want 1 unchanged and no failures", stats)
+	}
+	if _, err := scraper.ScrapeURL(context.Background(), urls[0]); !errors.Is(err, ErrMagazineUnchanged) {
+		t.Errorf("ScrapeURL() error = %v, want ErrMagazineUnchanged", err)
+	}
+
+	// A newer update time makes it scrape again
+	updated.Store("2024-05-02T08:00:00Z")
+	articles, err = scraper.ScrapeURLs(context.Background(), urls)
+	if err != nil || len(articles) != 1 {
+		t.Errorf("updated run: got %d articles, error = %v", len(articles), err)
+	}
+}
+
+func TestUpdateTrackerScrapesMagazinesWithoutUpdateTime(t *testing.T) {
+	page := `<html><body><article class="item"><h3>Story</h3><a href="https://example.com/story">Read</a></article></body></html>`
+	tracker, err := LoadUpdateTracker(filepath.Join(t.TempDir(), "updates.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Updates = tracker
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+
+	for run := 1; run <= 2; run++ {
+		articles, err := scraper.ScrapeURL(context.Background(), "https://flipboard.com/@user/tech")
+		if err != nil || len(articles) != 1 {
+			t.Errorf("run %d: got %d articles, error = %v", run, len(articles), err)
+		}
+	}
+}
+
+func TestUpdateTrackerStagesUntilSave(t *testing.T) {
+	page := `<html><body>
+<header class="magazine-header"><time datetime="2024-05-01T08:00:00Z">Updated</time></header>
+<article class="item"><h3>Story</h3><a href="https://example.com/story">Read</a></article>
+</body></html>`
+	tracker, err := LoadUpdateTracker(filepath.Join(t.TempDir(), "updates.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Updates = tracker
+	scraper := newFixtureScraper(t, config, fixtureHandler(page))
+	url := "https://flipboard.com/@user/tech"
+
+	// Without a Save, as after a failed export, the magazine is scraped again
+	for run := 1; run <= 2; run++ {
+		if articles, err := scraper.ScrapeURL(context.Background(), url); err != nil || len(articles) != 1 {
+			t.Fatalf("run %d: got %d articles, error = %v", run, len(articles), err)
+		}
+	}
+
+	// A discarded run isn't committed by a later Save
+	tracker.Discard()
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := scraper.ScrapeURL(context.Background(), url); err != nil {
+		t.Fatalf("after Discard: error = %v, want the magazine scraped", err)
+	}
+
+	// Once saved, the magazine is unchanged
+	if err := tracker.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := scraper.ScrapeURL(context.Background(), url); !errors.Is(err, ErrMagazineUnchanged) {
+		t.Errorf("after Save: error = %v, want ErrMagazineUnchanged", err)
+	}
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+)
+
+// runWorkers calls fn for every URL on a fixed pool of workers goroutines
+// fed from a channel, instead of starting a goroutine per URL. The first
+// error cancels the context passed to fn and is returned once every URL has
+// been handed out; URLs still queued at that point see the cancelled context.
+// A non-positive workers count means one worker per URL.
+func runWorkers(ctx context.Context, workers int, urls []string, fn func(ctx context.Context, url string) error) error {
+	if workers <= 0 || workers > len(urls) {
+		workers = len(urls)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	work := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range work {
+				if err := fn(ctx, url); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for _, url := range urls {
+		work <- url
+	}
+	close(work)
+	wg.Wait()
+	return firstErr
+}
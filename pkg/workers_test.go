@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestRunWorkersVisitsEveryURLOnceWithinLimit(t *testing.T) {
+	urls := make([]string, 50)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://flipboard.com/@user/m-%d", i)
+	}
+
+	var (
+		mu       sync.Mutex
+		seen     = map[string]int{}
+		inFlight int32
+		peak     int32
+	)
+	err := runWorkers(context.Background(), 4, urls, func(ctx context.Context, url string) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		seen[url]++
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runWorkers() error = %v", err)
+	}
+
+	if len(seen) != len(urls) {
+		t.Errorf("visited %d URLs, want %d", len(seen), len(urls))
+	}
+	for url, n := range seen {
+		if n != 1 {
+			t.Errorf("%s visited %d times", url, n)
+		}
+	}
+	if peak > 4 {
+		t.Errorf("peak concurrency = %d, want at most 4", peak)
+	}
+}
+
+func TestRunWorkersFirstErrorCancelsRest(t *testing.T) {
+	urls := []string{"a", "b", "c", "d", "e", "f"}
+	boom := errors.New("boom")
+
+	var cancelled atomic.Int32
+	err := runWorkers(context.Background(), 1, urls, func(ctx context.Context, url string) error {
+		if url == "b" {
+			return boom
+		}
+		if ctx.Err() != nil {
+			cancelled.Add(1)
+			return ctx.Err()
+		}
+		return nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("runWorkers() error = %v, want %v", err, boom)
+	}
+	if got := cancelled.Load(); got != 4 {
+		t.Errorf("%d URLs saw a cancelled context, want 4", got)
+	}
+}
+
+func TestScrapeURLsWithWorkerPool(t *testing.T) {
+	config := DefaultConfig()
+	config.ConcurrentRequests = 2
+	config.RequestsPerSecond = 1000
+	scraper := newFixtureScraper(t, config, fixtureHandler(`<html><body>
+<article class="item"><h3>Title</h3><a href="https://example.com/story">Read</a></article>
+</body></html>`))
+
+	urls := make([]string, 7)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://flipboard.com/@user/magazine-%d", i)
+	}
+	articles, err := scraper.ScrapeURLs(context.Background(), urls)
+	if err != nil {
+		t.Fatalf("ScrapeURLs() error = %v", err)
+	}
+	if len(articles) != len(urls) {
+		t.Errorf("got %d articles, want %d", len(articles), len(urls))
+	}
+	if stats := scraper.LastStats(); stats.URLs != len(urls) || stats.Failures != 0 {
+		t.Errorf("LastStats() = %+v", stats)
+	}
+}
+
+// benchmarkURLs is large enough for goroutine churn to show up
+var benchmarkURLs = func() []string {
+	urls := make([]string, 10000)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://flipboard.com/@user/m-%d", i)
+	}
+	return urls
+}()
+
+func BenchmarkRunWorkers(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = runWorkers(context.Background(), 8, benchmarkURLs, func(context.Context, string) error {
+			return nil
+		})
+	}
+}
+
+// BenchmarkErrgroupPerURL is the goroutine-per-URL model runWorkers replaced
+func BenchmarkErrgroupPerURL(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g, _ := errgroup.WithContext(context.Background())
+		g.SetLimit(8)
+		for _, url := range benchmarkURLs {
+			url := url
+			g.Go(func() error {
+				_ = url
+				return nil
+			})
+		}
+		_ = g.Wait()
+	}
+}
+
+func TestOnProgress(t *testing.T) {
+	page := `<html><body><article class="item"><h3>One</h3><a href="https://example.com/1">Read</a></article></body></html>`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/@user/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	})
+	var mu sync.Mutex
+	var dones []int
+	seen := make(map[string]int)
+	config := DefaultConfig()
+	config.RequestsPerSecond = 1000
+	config.ContinueOnError = true
+	config.OnProgress = func(done, total int, url string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if total != 3 {
+			t.Errorf("total = %d, want 3", total)
+		}
+		dones = append(dones, done)
+		seen[url]++
+	}
+	scraper := newFixtureScraper(t, config, handler)
+
+	urls := []string{
+		"https://flipboard.com/@user/a",
+		"https://flipboard.com/@user/missing",
+		"https://flipboard.com/@user/c",
+	}
+	scraper.ScrapeURLs(context.Background(), urls)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(dones) != "[1 2 3]" {
+		t.Errorf("done values = %v, want [1 2 3]", dones)
+	}
+	for _, url := range urls {
+		if seen[url] != 1 {
+			t.Errorf("%s reported %d times, want once", url, seen[url])
+		}
+	}
+}